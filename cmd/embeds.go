@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer"
+)
+
+var (
+	embedsConfigPath string
+	disabledEmbeds   []string
+	embedApiKeys     map[string]string
+	embedIframeHosts []string
+	embedSandbox     []string
+	embedConsent     bool
+	krokiUrl         string
+	inlineKroki      bool
+)
+
+func addEmbedsFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&embedsConfigPath, "embeds-config", "", "json file with embeds settings, flags override it")
+	flags.StringSliceVar(&disabledEmbeds, "disable-embed", nil, "providers rendered as link cards, e.g. Youtube,Figma")
+	flags.StringToStringVar(&embedApiKeys, "embed-api-key", nil, "provider api key, e.g. GoogleMaps=KEY")
+	flags.StringArrayVar(&embedIframeHosts, "embed-iframe-host", nil, "additional iframe host allowed for provider, e.g. Youtube=example.com")
+	flags.StringArrayVar(&embedSandbox, "embed-sandbox", nil, "iframe sandbox permissions for provider, e.g. Figma=allow-scripts,allow-same-origin")
+	flags.BoolVar(&embedConsent, "embed-consent", false, "render third-party embeds as click-to-load placeholders")
+	flags.StringVar(&krokiUrl, "kroki-url", "", "kroki instance for diagrams, default "+renderer.DefaultKrokiUrl)
+	flags.BoolVar(&inlineKroki, "inline-kroki", false, "fetch kroki diagrams during rendering and inline them")
+}
+
+// splitProviderFlag splits "Provider=value" flag
+func splitProviderFlag(flag, value string) (string, string, error) {
+	provider, rest, ok := strings.Cut(value, "=")
+	if !ok || provider == "" || rest == "" {
+		return "", "", fmt.Errorf("--%s: expected Provider=value, got %q", flag, value)
+	}
+	return provider, rest, nil
+}
+
+// loadEmbedsConfig reads --embeds-config file and applies embeds flags on top of it
+func loadEmbedsConfig(cmd *cobra.Command) (config renderer.EmbedsConfig, err error) {
+	if embedsConfigPath != "" {
+		var data []byte
+		data, err = os.ReadFile(embedsConfigPath)
+		if err != nil {
+			return config, fmt.Errorf("error reading embeds config: %w", err)
+		}
+		if err = json.Unmarshal(data, &config); err != nil {
+			return config, fmt.Errorf("error parsing embeds config: %w", err)
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("disable-embed") {
+		config.Disabled = append(config.Disabled, disabledEmbeds...)
+	}
+	if len(embedApiKeys) != 0 && config.ApiKeys == nil {
+		config.ApiKeys = make(map[string]string)
+	}
+	for provider, key := range embedApiKeys {
+		config.ApiKeys[provider] = key
+	}
+	for _, value := range embedIframeHosts {
+		provider, host, err := splitProviderFlag("embed-iframe-host", value)
+		if err != nil {
+			return config, err
+		}
+		if config.IframeHosts == nil {
+			config.IframeHosts = make(map[string][]string)
+		}
+		config.IframeHosts[provider] = append(config.IframeHosts[provider], host)
+	}
+	for _, value := range embedSandbox {
+		provider, permissions, err := splitProviderFlag("embed-sandbox", value)
+		if err != nil {
+			return config, err
+		}
+		if config.Sandbox == nil {
+			config.Sandbox = make(map[string][]string)
+		}
+		config.Sandbox[provider] = strings.Split(permissions, ",")
+	}
+	if flags.Changed("embed-consent") {
+		config.Consent = embedConsent
+	}
+	if flags.Changed("kroki-url") {
+		config.KrokiUrl = krokiUrl
+	}
+	if flags.Changed("inline-kroki") {
+		config.InlineKroki = inlineKroki
+	}
+	return config, nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer"
+)
+
+var (
+	exportStaticDir string
+	exportAssetsUrl string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   `export <snapshot-path> <out-dir>`,
+	Args:  cobra.ExactArgs(2),
+	Short: "Export Anytype web publish package as a directory for static hosting",
+	Long: `Export writes index.html, the package files and static files it references, and manifest.json into out-dir.
+Files are renamed by content hash, so they can be cached forever.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := renderConfig(cmd, args[0])
+		if err != nil {
+			log.Error("error reading embeds settings", zap.Error(err))
+			return
+		}
+		config.StaticFilesPath = exportStaticDir
+		config.Export = &renderer.ExportConfig{
+			Dir:       args[1],
+			AssetsUrl: exportAssetsUrl,
+		}
+
+		r, err := renderer.NewRenderer(config)
+		if err != nil {
+			log.Error("error creating renderer", zap.Error(err))
+			return
+		}
+
+		manifest, err := r.Export()
+		if err != nil {
+			log.Error("error exporting page", zap.Error(err))
+			return
+		}
+		log.Info("page is exported", zap.String("dir", args[1]), zap.Int("assets", len(manifest.Assets)))
+	},
+}
+
+func init() {
+	flags := exportCmd.Flags()
+	flags.StringVar(&exportStaticDir, "static-dir", "static", "directory with built static files")
+	flags.StringVar(&exportAssetsUrl, "assets-url", "", "url of assets directory on the published page, e.g. on a cdn, relative to the page if empty")
+	addEmbedsFlags(exportCmd)
+	addImagesFlags(exportCmd)
+	addLinksFlags(exportCmd)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer"
+)
+
+var (
+	derivativesDir string
+	derivativesUrl string
+	imageWidths    []int
+)
+
+func addImagesFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&derivativesDir, "image-derivatives", "", "directory to write resized copies of images to, images are not resized if empty")
+	flags.StringVar(&derivativesUrl, "image-derivatives-url", "", "url of image derivatives directory on the published page, the directory path if empty")
+	flags.IntSliceVar(&imageWidths, "image-widths", renderer.DefaultDerivativeWidths, "widths of image derivatives")
+}
+
+// imageDerivatives returns derivatives hook for RenderConfig, nil if they are not enabled
+func imageDerivatives() renderer.ImageDerivativesFunc {
+	if derivativesDir == "" {
+		return nil
+	}
+	url := derivativesUrl
+	if url == "" {
+		url = derivativesDir
+	}
+	return renderer.NewImageDeriver(derivativesDir, url, imageWidths).Derivatives
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer"
+)
+
+var objectUrl string
+
+func addLinksFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&objectUrl, "object-url", "", "url of published objects with {objectId} and {spaceId} placeholders, objects are opened in anytype if empty")
+}
+
+// linkResolver returns resolver for RenderConfig, nil for default anytype links
+func linkResolver() renderer.LinkResolver {
+	if objectUrl == "" {
+		return nil
+	}
+	return renderer.UrlTemplateLinkResolver{Template: objectUrl}
+}
@@ -17,14 +17,12 @@ var pbCmd = &cobra.Command{
 	Args:  cobra.MinimumNArgs(1),
 	Short: "Convert Anytype web publish package to HTML",
 	Run: func(cmd *cobra.Command, args []string) {
-		snapshotPath := args[0]
-		config := renderer.RenderConfig{
-			StaticFilesPath:  "/static",
-			PublishFilesPath: snapshotPath,
-			PrismJsCdnUrl:    "https://cdn.jsdelivr.net/npm/prismjs@1.29.0",
-			AnytypeCdnUrl:    "https://anytype-static.fra1.cdn.digitaloceanspaces.com",
-			AnalyticsCode:    `<script>console.log("sending dummy analytics...")</script>`,
+		config, err := renderConfig(cmd, args[0])
+		if err != nil {
+			log.Error("error reading embeds settings", zap.Error(err))
+			return
 		}
+		applySingleFile(&config)
 
 		r, err := renderer.NewRenderer(config)
 		if err != nil {
@@ -41,6 +39,32 @@ var pbCmd = &cobra.Command{
 	},
 }
 
+// renderConfig makes config shared by rendering and export
+func renderConfig(cmd *cobra.Command, snapshotPath string) (renderer.RenderConfig, error) {
+	embeds, err := loadEmbedsConfig(cmd)
+	if err != nil {
+		return renderer.RenderConfig{}, err
+	}
+	return renderer.RenderConfig{
+		StaticFilesPath:  "/static",
+		PublishFilesPath: snapshotPath,
+		PrismJsCdnUrl:    "https://cdn.jsdelivr.net/npm/prismjs@1.29.0",
+		AnytypeCdnUrl:    "https://anytype-static.fra1.cdn.digitaloceanspaces.com",
+		AnalyticsCode:    `<script>console.log("sending dummy analytics...")</script>`,
+		Embeds:           embeds,
+		ImageDerivatives: imageDerivatives(),
+		LinkResolver:     linkResolver(),
+	}, nil
+}
+
+func init() {
+	addEmbedsFlags(pbCmd)
+	addImagesFlags(pbCmd)
+	addLinksFlags(pbCmd)
+	addSingleFileFlags(pbCmd)
+	pbCmd.AddCommand(exportCmd)
+}
+
 func Execute() {
 	if err := pbCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer"
+)
+
+var (
+	singleFile  bool
+	staticDir   string
+	assetBudget int64
+	pageBudget  int64
+)
+
+func addSingleFileFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVar(&singleFile, "single-file", false, "render one html file with inlined css, js and images, which makes no external requests")
+	flags.StringVar(&staticDir, "static-dir", "static", "directory with built static files, inlined into single file page")
+	flags.Int64Var(&assetBudget, "asset-budget", renderer.DefaultAssetBudget, "inlined assets bigger than this number of bytes are reported")
+	flags.Int64Var(&pageBudget, "page-budget", renderer.DefaultPageBudget, "single file pages bigger than this number of bytes are reported")
+}
+
+// applySingleFile switches config to single file export, if it is enabled
+func applySingleFile(config *renderer.RenderConfig) {
+	if !singleFile {
+		return
+	}
+	config.StaticFilesPath = staticDir
+	config.SingleFile = &renderer.SingleFileConfig{
+		AssetBudget: assetBudget,
+		PageBudget:  pageBudget,
+	}
+}
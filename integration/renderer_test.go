@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/anyproto/anytype-publish-renderer/renderer"
 )
@@ -23,6 +26,10 @@ func TestRenderer(t *testing.T) {
 		testDir := "primitives"
 		testRendering(t, testDir)
 	})
+	t.Run("test nested lists snapshot", func(t *testing.T) {
+		testDir := "lists"
+		testRendering(t, testDir)
+	})
 }
 
 func testRendering(t *testing.T, testDir string) {
@@ -82,3 +89,121 @@ func makeTestRenderer(dir string) (*renderer.Renderer, error) {
 
 	return r, nil
 }
+
+func TestSingleFile(t *testing.T) {
+	// given
+	static := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(static, "js", "build"), 0o755))
+	for _, file := range []string{"img", "embed", filepath.Join("js", "jquery.js")} {
+		source, err := filepath.Abs(filepath.Join("..", "static", file))
+		require.NoError(t, err)
+		require.NoError(t, os.Symlink(source, filepath.Join(static, file)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(static, "js", "loader.js"), []byte(`const chunks = ["main.js"]; const cssFiles = ["main.css"];`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(static, "js", "build", "main.js"), []byte("console.log('main');"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(static, "js", "build", "main.css"), []byte("body { opacity: 1; }"), 0o644))
+
+	r, err := renderer.NewRenderer(renderer.RenderConfig{
+		StaticFilesPath:  static,
+		PublishFilesPath: "testdata",
+		AnytypeCdnUrl:    "https://anytype-static.fra1.cdn.digitaloceanspaces.com",
+		AnalyticsCode:    `<script src="https://analytics.example.com/script.js"></script>`,
+		SingleFile:       &renderer.SingleFileConfig{},
+	})
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+
+	// when
+	err = r.Render(buffer)
+
+	// then
+	require.NoError(t, err)
+	html := buffer.String()
+	assert.Contains(t, html, `<style type="text/css">body { opacity: 1; }</style>`)
+	assert.Contains(t, html, `<script type="text/javascript">console.log('main');</script>`)
+	assert.Contains(t, html, `src="data:image/jpeg;base64,`)
+	assert.Contains(t, html, `src="data:image/svg+xml;base64,`)
+	assert.Contains(t, html, `src="data:text/html;charset=utf-8;base64,`)
+	for _, external := range []string{"loader.js", "analytics.example.com", "anytype-static", "anytype.io/favicon", `src="testdata/files/jpg.jpg"`, `src="` + static} {
+		assert.NotContains(t, html, external)
+	}
+}
+
+func TestStaticFilesPath(t *testing.T) {
+	t.Run("pages have no hardcoded static path", func(t *testing.T) {
+		for _, dir := range []string{"testdata", "primitives", "lists"} {
+			// given
+			r, err := renderer.NewRenderer(renderer.RenderConfig{
+				StaticFilesPath:  "https://cdn.example.com/pages/assets",
+				PublishFilesPath: dir,
+			})
+			require.NoError(t, err)
+			buffer := bytes.NewBuffer(nil)
+
+			// when
+			err = r.Render(buffer)
+
+			// then
+			require.NoError(t, err)
+			html := buffer.String()
+			assert.Contains(t, html, `"https://cdn.example.com/pages/assets/`)
+			for _, prefix := range []string{`"/static`, `'/static`, `(/static`} {
+				assert.NotContains(t, html, prefix, dir)
+			}
+		}
+	})
+	t.Run("static files have no hardcoded static path", func(t *testing.T) {
+		for _, file := range []string{filepath.Join("js", "loader.tmpl.js"), filepath.Join("embed", "iframe.html")} {
+			content, err := os.ReadFile(filepath.Join("..", "static", file))
+			require.NoError(t, err)
+
+			assert.NotContains(t, string(content), "/static/", file)
+		}
+	})
+}
+
+// loaderJs runs loader of the static build in node with the page script tag
+// and returns urls of build files it adds to the page
+func loaderJs(t *testing.T, src, build string) []string {
+	loader, err := os.ReadFile(filepath.Join("..", "static", "js", "loader.tmpl.js"))
+	require.NoError(t, err)
+	code := strings.NewReplacer(`%CHUNKS%`, `["main.js"]`, `%CSS%`, `["main.css"]`).Replace(string(loader))
+	page := fmt.Sprintf(`const added = [];
+global.document = {
+	currentScript: { src: %q, getAttribute: name => name === 'data-build' ? %s : null },
+	createElement: () => ({}),
+	head: { appendChild: el => added.push(el.href || el.src) },
+};
+%s
+console.log(added.join('\n'));`, src, build, code)
+
+	cmd := exec.Command("node", "-")
+	cmd.Stdin = strings.NewReader(page)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.Fields(string(out))
+}
+
+func TestStaticBuildLoader(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+	r, err := renderer.NewRenderer(renderer.RenderConfig{
+		StaticFilesPath:  "https://cdn.example.com/pages/assets",
+		PublishFilesPath: "testdata",
+	})
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, r.Render(buffer))
+	tag := regexp.MustCompile(`<script src="([^"]*/loader\.js)" data-build="([^"]*)"`).FindStringSubmatch(buffer.String())
+	require.NotNil(t, tag)
+	expected := []string{"https://cdn.example.com/pages/assets/js/build/main.css", "https://cdn.example.com/pages/assets/js/build/main.js"}
+
+	t.Run("build directory from data-build", func(t *testing.T) {
+		assert.Equal(t, expected, loaderJs(t, "https://other.example.com/js/loader.js", fmt.Sprintf("%q", tag[2])))
+	})
+	t.Run("build directory next to loader without data-build", func(t *testing.T) {
+		assert.Equal(t, expected, loaderJs(t, tag[1], "null"))
+		assert.Equal(t, expected, loaderJs(t, tag[1]+"?v=1", "null"))
+	})
+}
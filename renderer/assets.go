@@ -0,0 +1,285 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	loaderListRe   = regexp.MustCompile(`const (chunks|cssFiles) = (\[[^\]]*\]);`)
+	cssUrlRe       = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+	assetMimeTypes = map[string]string{
+		".vtt":   "text/vtt",
+		".woff2": "font/woff2",
+		".mp3":   "audio/mpeg",
+		".m4a":   "audio/mp4",
+		".mp4":   "video/mp4",
+	}
+	// attributes with urls which browser or page scripts load by themselves
+	loadedAttributes = []string{"src", "poster", "data-src", "data-full"}
+	// meta tags with urls, which are used by link previews
+	metaImages = []string{"og:image", "twitter:image"}
+)
+
+// staticBuildFiles returns names of css and js files which loader.js adds to regular pages
+func (r *Renderer) staticBuildFiles() (css, js []string, err error) {
+	jsDir := filepath.Join(r.Config.StaticFilesPath, "js")
+	loader, err := os.ReadFile(filepath.Join(jsDir, "loader.js"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read static build loader: %w", err)
+	}
+
+	lists := make(map[string][]string)
+	for _, match := range loaderListRe.FindAllSubmatch(loader, -1) {
+		var files []string
+		if err := json.Unmarshal(match[2], &files); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s list of loader.js: %w", match[1], err)
+		}
+		for i, file := range files {
+			files[i] = filepath.Base(file)
+		}
+		lists[string(match[1])] = files
+	}
+	if len(lists["chunks"]) == 0 {
+		return nil, nil, fmt.Errorf("no chunks in loader.js of %s", r.Config.StaticFilesPath)
+	}
+	return lists["cssFiles"], lists["chunks"], nil
+}
+
+func (r *Renderer) staticBuildPath(name string) string {
+	return filepath.Join(r.Config.StaticFilesPath, "js", "build", name)
+}
+
+// localAsset is a local file referenced by the page
+type localAsset struct {
+	Path string
+	// url in the page
+	Url  string
+	Data []byte
+	// referenced by a page which is an asset itself, e.g. embed iframe
+	Nested bool
+}
+
+// assetRewriter replaces urls of local files in rendered html
+type assetRewriter struct {
+	// directories files may be taken from
+	roots []string
+	// rewrite links and link preview images too, not only urls which are loaded with the page
+	links bool
+	// returns new url of a local file
+	rewrite func(asset localAsset) (string, error)
+	// path -> new url, empty if file can't be rewritten
+	urls map[string]string
+	// directory relative urls are resolved from, of a nested page
+	base string
+}
+
+func (r *Renderer) newAssetRewriter(links bool, rewrite func(asset localAsset) (string, error)) *assetRewriter {
+	rw := &assetRewriter{
+		links:   links,
+		rewrite: rewrite,
+		urls:    make(map[string]string),
+	}
+	for _, root := range []string{r.Config.StaticFilesPath, r.Config.PublishFilesPath, r.Config.EmojiAssetsPath} {
+		if root != "" && !strings.Contains(root, "://") {
+			rw.roots = append(rw.roots, filepath.Clean(root))
+		}
+	}
+	return rw
+}
+
+func (rw *assetRewriter) rewriteHtml(page []byte) ([]byte, error) {
+	z := xhtml.NewTokenizer(bytes.NewReader(page))
+	var out bytes.Buffer
+	out.Grow(len(page))
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			if z.Err() == io.EOF {
+				return out.Bytes(), nil
+			}
+			return nil, z.Err()
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			raw := slices.Clone(z.Raw())
+			token := z.Token()
+			if rw.rewriteAttributes(&token) {
+				out.WriteString(token.String())
+			} else {
+				out.Write(raw)
+			}
+		default:
+			out.Write(z.Raw())
+		}
+	}
+}
+
+func (rw *assetRewriter) rewriteAttributes(token *xhtml.Token) (changed bool) {
+	for i, attr := range token.Attr {
+		var value string
+		switch {
+		case attr.Key == "srcset":
+			value = rw.rewriteSrcset(attr.Val)
+		case attr.Key == "style":
+			value = cssUrlRe.ReplaceAllStringFunc(attr.Val, func(match string) string {
+				src := cssUrlRe.FindStringSubmatch(match)[1]
+				if newUrl := rw.url(src); newUrl != src {
+					return fmt.Sprintf(`url("%s")`, newUrl)
+				}
+				return match
+			})
+		case slices.Contains(loadedAttributes, attr.Key),
+			attr.Key == "href" && (token.Data == "link" || rw.links),
+			attr.Key == "content" && rw.links && isMetaImage(token):
+			value = rw.url(attr.Val)
+		default:
+			continue
+		}
+		if value != attr.Val {
+			token.Attr[i].Val = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+func isMetaImage(token *xhtml.Token) bool {
+	if token.Data != "meta" {
+		return false
+	}
+	for _, attr := range token.Attr {
+		if (attr.Key == "property" || attr.Key == "name") && slices.Contains(metaImages, attr.Val) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rw *assetRewriter) rewriteSrcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = rw.url(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// localPath returns path of file which url points to, if it is inside one of the roots
+func (rw *assetRewriter) localPath(rawUrl string) (string, bool) {
+	// urls with scheme are not local, e.g. data uris, web and anytype links,
+	// file names are not always valid urls though
+	u, err := url.Parse(rawUrl)
+	if rawUrl == "" || err == nil && (u.Scheme != "" || u.Path == "") {
+		return "", false
+	}
+	candidates := []string{rawUrl}
+	// package file urls are partially escaped, see getFileUrl
+	if unescaped, err := url.PathUnescape(rawUrl); err == nil && unescaped != rawUrl {
+		candidates = append(candidates, unescaped)
+	}
+	var local []string
+	for _, candidate := range candidates {
+		path := filepath.FromSlash(candidate)
+		if rw.base != "" {
+			path = filepath.Join(rw.base, path)
+		}
+		path = filepath.Clean(path)
+		for _, root := range rw.roots {
+			if isInsideDir(root, path) {
+				local = append(local, path)
+				break
+			}
+		}
+	}
+	for _, path := range local {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	// missing files are reported when they are read
+	if len(local) > 0 {
+		return local[0], true
+	}
+	return "", false
+}
+
+// isInsideDir reports whether cleaned path is the root or is under it
+func isInsideDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// url returns new url of a local file, or url itself if it is not local
+func (rw *assetRewriter) url(rawUrl string) string {
+	path, ok := rw.localPath(rawUrl)
+	if !ok {
+		return rawUrl
+	}
+	newUrl, ok := rw.urls[path]
+	if !ok {
+		var err error
+		if newUrl, err = rw.rewriteAsset(path, rawUrl); err != nil {
+			log.Warn("failed to rewrite asset url", zap.String("path", path), zap.Error(err))
+			newUrl = ""
+		}
+		rw.urls[path] = newUrl
+	}
+	if newUrl == "" {
+		return rawUrl
+	}
+	return newUrl
+}
+
+func (rw *assetRewriter) rewriteAsset(path, rawUrl string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	// pages of the page, only one level deep
+	if rw.base == "" && (ext == ".html" || ext == ".htm") {
+		nested := &assetRewriter{
+			roots: rw.roots,
+			links: rw.links,
+			rewrite: func(asset localAsset) (string, error) {
+				asset.Nested = true
+				return rw.rewrite(asset)
+			},
+			urls: make(map[string]string),
+			base: filepath.Dir(path),
+		}
+		if data, err = nested.rewriteHtml(data); err != nil {
+			return "", err
+		}
+	}
+	return rw.rewrite(localAsset{Path: path, Url: rawUrl, Data: data})
+}
+
+// assetMimeType prefers extension, as mime.types of the system may not know some of them
+func assetMimeType(path string, data []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if mimeType, ok := assetMimeTypes[ext]; ok {
+		return mimeType
+	}
+	mimeType := mime.TypeByExtension(ext)
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return strings.ReplaceAll(mimeType, " ", "")
+}
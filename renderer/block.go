@@ -61,6 +61,10 @@ type BlockParams struct {
 	Additional        templ.Component
 	ChildrenIds       []string
 	Width             string
+	// render block as <details> with content in <summary>, see RenderConfig.SemanticHtml
+	IsDetails bool
+	// text direction, if it differs from the page one
+	Dir string
 }
 
 type BlockWrapperParams struct {
@@ -46,8 +46,8 @@ func (r *Renderer) getDetailsFromBlock(bookmark *model.BlockContentBookmark) *ty
 }
 
 func (r *Renderer) getBookmarkBlockParams(b *model.Block, details *types.Struct) *BlockParams {
-	bookmarkUrl := r.getBookmarkUrl(b, details)
-	if bookmarkUrl == "" {
+	bookmarkUrl, ok := r.sanitizeUrl(r.getBookmarkUrl(b, details))
+	if !ok {
 		return nil
 	}
 	parsedUrl, err := url.Parse(bookmarkUrl)
@@ -65,7 +65,7 @@ func (r *Renderer) getBookmarkBlockParams(b *model.Block, details *types.Struct)
 	sideLeft := r.getSideLeftComponent(details, parsedUrl)
 	sideRightComponents, innerClasses := r.getSideRightComponent(details, innerClasses)
 	blockParams := makeDefaultBlockParams(b)
-	blockParams.Content = BookmarkLinkTemplate(templ.URL(bookmarkUrl), innerClasses, []templ.Component{sideLeft, sideRightComponents})
+	blockParams.Content = BookmarkLinkTemplate(templ.SafeURL(bookmarkUrl), innerClasses, []templ.Component{sideLeft, sideRightComponents})
 	return blockParams
 }
 
@@ -62,6 +62,7 @@ func TestMakeBookmarkRendererParams(t *testing.T) {
 				expectedValue string
 			}{
 				{"a > attrs[href]", "https://example.com"},
+				{"a > attrs[rel]", "noopener noreferrer nofollow"},
 				{"a > div > attrs[class]", "side left"},
 				{"a > div > div.link > Content", "example.com"},
 				{"a > div > div.name > Content", "name1"},
@@ -124,6 +125,30 @@ func TestMakeBookmarkRendererParams(t *testing.T) {
 			},
 			expected: nil,
 		},
+		{
+			name: "javascript URL",
+			block: &model.Block{
+				Id: "block4",
+				Content: &model.BlockContentOfBookmark{
+					Bookmark: &model.BlockContentBookmark{
+						TargetObjectId: "object4",
+						Url:            "javascript:alert(1)",
+					},
+				},
+			},
+			pbFiles: map[string]*pb.SnapshotWithType{
+				filepath.Join("objects", "object4.pb"): {
+					SbType: model.SmartBlockType_Page,
+					Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{Fields: map[string]*types.Value{
+							bundle.RelationKeyName.String():   pbtypes.String("name4"),
+							bundle.RelationKeySource.String(): pbtypes.String(" JavaScript:alert(1)"),
+						}},
+					}},
+				},
+			},
+			expected: nil,
+		},
 		{
 			name: "empty URL",
 			block: &model.Block{
@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+)
+
+// MediaTrack is a subtitles file of a video
+type MediaTrack struct {
+	Src   string
+	Lang  string
+	Label string
+}
+
+type packageFile struct {
+	Id     string
+	Source string
+	Name   string
+}
+
+// subtitleFiles returns .vtt file objects of the package, sorted by source
+func (r *Renderer) subtitleFiles() []packageFile {
+	if r.subtitles == nil {
+		r.subtitles = []packageFile{}
+		for filePath := range r.UberSp.PbFiles {
+			if !strings.HasPrefix(filePath, "filesObjects/") {
+				continue
+			}
+			snapshot, err := r.ReadJsonpbSnapshot(filePath)
+			if err != nil {
+				continue
+			}
+			details := snapshot.GetSnapshot().GetData().GetDetails()
+			source := getRelationField(details, bundle.RelationKeySource, relationToString)
+			if !strings.EqualFold(path.Ext(source), ".vtt") {
+				continue
+			}
+			r.subtitles = append(r.subtitles, packageFile{
+				Id:     strings.TrimSuffix(path.Base(filePath), pbExt),
+				Source: source,
+				Name:   getRelationField(details, bundle.RelationKeyName, relationToString),
+			})
+		}
+		slices.SortFunc(r.subtitles, func(a, b packageFile) int {
+			return strings.Compare(a.Source, b.Source)
+		})
+	}
+	return r.subtitles
+}
+
+// videoTracks finds subtitles next to the video in the package: "video.vtt" or "video.<lang>.vtt"
+func (r *Renderer) videoTracks(videoSource string) []MediaTrack {
+	if videoSource == "" {
+		return nil
+	}
+	base := strings.TrimSuffix(videoSource, path.Ext(videoSource))
+
+	var tracks []MediaTrack
+	for _, file := range r.subtitleFiles() {
+		rest, ok := strings.CutPrefix(strings.TrimSuffix(file.Source, path.Ext(file.Source)), base)
+		if !ok {
+			continue
+		}
+		lang := r.Lang
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || strings.ContainsAny(rest[1:], "./") {
+				continue
+			}
+			lang = rest[1:]
+		}
+		src, err := r.getFileUrl(file.Id)
+		if err != nil {
+			continue
+		}
+		if src, ok = r.sanitizeUrl(src); !ok {
+			continue
+		}
+		label := file.Name
+		if label == "" {
+			label = lang
+		}
+		tracks = append(tracks, MediaTrack{Src: src, Lang: lang, Label: label})
+	}
+	return tracks
+}
+
+// fillMediaText sets alternative text and caption from file details.
+// Description is shown as caption, so alt is the name and screen readers don't read it twice
+func (r *Renderer) fillMediaText(params *FileMediaRenderParams, fileParams *FileRenderParams) {
+	params.Alt = fileParams.Name
+	params.Caption = fileParams.Description
+}
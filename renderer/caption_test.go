@@ -0,0 +1,102 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func mediaBlock(id, targetId string, fileType model.BlockContentFileType) *model.Block {
+	b := imageBlock(id, targetId)
+	b.GetFile().Type = fileType
+	return b
+}
+
+func fileObject(t *testing.T, id, source, name, description string) Option {
+	return WithLinkedSnapshot(t, "filesObjects/"+id+pbExt, &pb.SnapshotWithType{
+		SbType: model.SmartBlockType_FileObject,
+		Snapshot: &pb.ChangeSnapshot{
+			Data: &model.SmartBlockSnapshotBase{Details: &types.Struct{Fields: map[string]*types.Value{
+				bundle.RelationKeyId.String():          pbtypes.String(id),
+				bundle.RelationKeySource.String():      pbtypes.String(source),
+				bundle.RelationKeyName.String():        pbtypes.String(name),
+				bundle.RelationKeyDescription.String(): pbtypes.String(description),
+			}}},
+		},
+	})
+}
+
+func TestMediaText(t *testing.T) {
+	t.Run("alt from name, no caption", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "image", "files/photo.jpg", "Photo", ""))
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Contains(t, html, `alt="Photo"`)
+		assert.NotContains(t, html, "<figure")
+	})
+	t.Run("caption from description, alt from name", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "image", "files/photo.jpg", "Photo", "Sunset over <the> lake"))
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Contains(t, html, `alt="Photo"`)
+		assert.NotContains(t, html, `alt="Sunset`)
+		assert.Contains(t, html, `<figure class="figure"><img`)
+		assert.Contains(t, html, `<figcaption class="caption">Sunset over &lt;the&gt; lake</figcaption></figure>`)
+	})
+	t.Run("audio is labeled", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "audio", "files/song.mp3", "Song", ""))
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "audio", model.BlockContentFile_Audio))
+
+		// then
+		assert.Contains(t, html, `<audio controls preload="metadata" src="/files/song.mp3" aria-label="Song"></audio>`)
+	})
+}
+
+func TestVideoTracks(t *testing.T) {
+	t.Run("subtitles next to the video", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(
+			fileObject(t, "video", "files/talk.mp4", "Talk", ""),
+			fileObject(t, "subs", "files/talk.vtt", "", ""),
+			fileObject(t, "subsDe", "files/talk.de.vtt", "Deutsch", ""),
+			fileObject(t, "other", "files/talk2.vtt", "", ""),
+			fileObject(t, "nested", "files/talk/sub.vtt", "", ""),
+		)
+		r.Lang = "en"
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "video", model.BlockContentFile_Video))
+
+		// then
+		assert.Contains(t, html, `<track kind="subtitles" src="/files/talk.de.vtt" srclang="de" label="Deutsch">`)
+		assert.Contains(t, html, `<track kind="subtitles" src="/files/talk.vtt" srclang="en" label="en">`)
+		assert.NotContains(t, html, "talk2.vtt")
+		assert.NotContains(t, html, "sub.vtt")
+	})
+	t.Run("no subtitles", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "video", "files/talk.mp4", "Talk", ""))
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "video", model.BlockContentFile_Video))
+
+		// then
+		assert.NotContains(t, html, "<track")
+	})
+}
@@ -0,0 +1,403 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/anytype-publish-renderer/utils"
+)
+
+const (
+	chartWidth  = 640
+	chartHeight = 360
+	// plot area paddings, legend and x labels take top and bottom ones
+	chartPadLeft   = 48
+	chartPadRight  = 16
+	chartPadTop    = 40
+	chartPadBottom = 32
+	chartTicks     = 5
+)
+
+// chart.js default colors
+var chartPalette = []string{"#36a2eb", "#ff6384", "#ff9f40", "#ffcd56", "#4bc0c0", "#9966ff", "#c9cbcf"}
+
+var chartColorRegexp = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|(rgb|rgba|hsl|hsla)\([0-9.,%\s]+\)|[a-zA-Z]+)$`)
+
+type chartDataset struct {
+	Label string
+	// NaN for missing values
+	Data   []float64
+	Colors []string
+}
+
+// chartSpec is what renderer understands of chart.js config
+type chartSpec struct {
+	Type     string
+	Title    string
+	Labels   []string
+	Datasets []chartDataset
+}
+
+func chartColor(value any, fallback string) string {
+	if s, ok := value.(string); ok && chartColorRegexp.MatchString(strings.TrimSpace(s)) {
+		return strings.TrimSpace(s)
+	}
+	return fallback
+}
+
+func chartLabel(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func chartSpecFromConfig(config map[string]any) (*chartSpec, error) {
+	spec := &chartSpec{}
+	spec.Type, _ = config["type"].(string)
+	switch spec.Type {
+	case "bar", "line", "pie", "doughnut":
+	default:
+		return nil, fmt.Errorf("unsupported chart type %q", spec.Type)
+	}
+
+	data, ok := config["data"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("chart has no data")
+	}
+	if labels, ok := data["labels"].([]any); ok {
+		for _, label := range labels {
+			spec.Labels = append(spec.Labels, chartLabel(label))
+		}
+	}
+	datasets, _ := data["datasets"].([]any)
+	for i, value := range datasets {
+		dataset, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("dataset %d is not an object", i)
+		}
+		values, ok := dataset["data"].([]any)
+		if !ok {
+			return nil, fmt.Errorf("dataset %d has no data", i)
+		}
+		parsed := chartDataset{Label: chartLabel(dataset["label"])}
+		for _, v := range values {
+			n, ok := v.(float64)
+			if !ok {
+				n = math.NaN()
+			}
+			parsed.Data = append(parsed.Data, n)
+		}
+		switch colors := dataset["backgroundColor"].(type) {
+		case []any:
+			for j, c := range colors {
+				parsed.Colors = append(parsed.Colors, chartColor(c, chartPalette[j%len(chartPalette)]))
+			}
+		default:
+			if c := chartColor(colors, ""); c != "" {
+				parsed.Colors = []string{c}
+			} else if c := chartColor(dataset["borderColor"], ""); c != "" {
+				parsed.Colors = []string{c}
+			}
+		}
+		spec.Datasets = append(spec.Datasets, parsed)
+	}
+	if len(spec.Datasets) == 0 {
+		return nil, fmt.Errorf("chart has no datasets")
+	}
+
+	count := 0
+	for _, d := range spec.Datasets {
+		count = max(count, len(d.Data))
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("chart has no values")
+	}
+	for len(spec.Labels) < count {
+		spec.Labels = append(spec.Labels, strconv.Itoa(len(spec.Labels)+1))
+	}
+
+	if options, ok := config["options"].(map[string]any); ok {
+		if plugins, ok := options["plugins"].(map[string]any); ok {
+			if title, ok := plugins["title"].(map[string]any); ok && title["display"] != false {
+				spec.Title = chartLabel(title["text"])
+			}
+		}
+	}
+	return spec, nil
+}
+
+// color of dataset, or of its i-th value if colors are set per value
+func (d *chartDataset) color(datasetIndex, i int) string {
+	switch {
+	case len(d.Colors) > 1:
+		return d.Colors[i%len(d.Colors)]
+	case len(d.Colors) == 1:
+		return d.Colors[0]
+	}
+	return chartPalette[datasetIndex%len(chartPalette)]
+}
+
+func (d *chartDataset) value(i int) float64 {
+	if i >= len(d.Data) {
+		return math.NaN()
+	}
+	return d.Data[i]
+}
+
+type chartText struct {
+	X, Y   float64
+	Anchor string
+	Text   string
+}
+
+type chartShape struct {
+	X, Y, Width, Height float64
+	Path                string
+	Color               string
+	Title               string
+}
+
+type chartLegendItem struct {
+	X     float64
+	Color string
+	Text  string
+}
+
+// ChartSvg is chart geometry, ready to be drawn
+type ChartSvg struct {
+	Width, Height int
+	Label         string
+	Title         string
+	Grid          []chartText
+	XLabels       []chartText
+	Bars          []chartShape
+	Lines         []chartShape
+	Points        []chartShape
+	Slices        []chartShape
+	Legend        []chartLegendItem
+}
+
+func formatChartNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*10)/10, 'f', -1, 64)
+}
+
+// chartScale finds round axis bounds and step for values range
+func chartScale(minValue, maxValue float64) (lo, hi, step float64) {
+	minValue, maxValue = min(minValue, 0), max(maxValue, 0)
+	if minValue == maxValue {
+		maxValue = 1
+	}
+	raw := (maxValue - minValue) / chartTicks
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step = magnitude * 10
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			step = m * magnitude
+			break
+		}
+	}
+	return math.Floor(minValue/step) * step, math.Ceil(maxValue/step) * step, step
+}
+
+func tickText(value, step float64) string {
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
+
+func (spec *chartSpec) legend(labels []string, color func(i int) string) []chartLegendItem {
+	var items []chartLegendItem
+	x := float64(chartPadLeft)
+	for i, label := range labels {
+		if label == "" {
+			continue
+		}
+		items = append(items, chartLegendItem{X: x, Color: color(i), Text: label})
+		// there is no text measuring, so width is approximated
+		x += 24 + float64(len([]rune(label)))*7
+	}
+	return items
+}
+
+func (spec *chartSpec) makeSvg() *ChartSvg {
+	svg := &ChartSvg{Width: chartWidth, Height: chartHeight, Title: spec.Title, Label: spec.Title}
+	if svg.Label == "" {
+		svg.Label = spec.Type + " chart"
+		if label := spec.Datasets[0].Label; label != "" {
+			svg.Label = label
+		}
+	}
+	switch spec.Type {
+	case "pie", "doughnut":
+		spec.drawPie(svg)
+	default:
+		spec.drawAxes(svg)
+	}
+	return svg
+}
+
+func (spec *chartSpec) drawAxes(svg *ChartSvg) {
+	left, right := float64(chartPadLeft), float64(chartWidth-chartPadRight)
+	top, bottom := float64(chartPadTop), float64(chartHeight-chartPadBottom)
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, d := range spec.Datasets {
+		for _, v := range d.Data {
+			if !math.IsNaN(v) {
+				minValue, maxValue = min(minValue, v), max(maxValue, v)
+			}
+		}
+	}
+	if math.IsInf(minValue, 1) {
+		minValue, maxValue = 0, 0
+	}
+	lo, hi, step := chartScale(minValue, maxValue)
+	y := func(v float64) float64 {
+		return bottom - (v-lo)/(hi-lo)*(bottom-top)
+	}
+
+	for tick := lo; tick <= hi+step/2; tick += step {
+		svg.Grid = append(svg.Grid, chartText{X: left, Y: y(tick), Anchor: "end", Text: tickText(tick, step)})
+	}
+
+	group := (right - left) / float64(len(spec.Labels))
+	for i, label := range spec.Labels {
+		svg.XLabels = append(svg.XLabels, chartText{X: left + (float64(i)+0.5)*group, Y: bottom + 18, Anchor: "middle", Text: label})
+	}
+
+	labels := make([]string, len(spec.Datasets))
+	for i, d := range spec.Datasets {
+		labels[i] = d.Label
+	}
+	svg.Legend = spec.legend(labels, func(i int) string {
+		return spec.Datasets[i].color(i, 0)
+	})
+
+	barWidth := group * 0.8 / float64(len(spec.Datasets))
+	for di, d := range spec.Datasets {
+		var segment []string
+		flush := func() {
+			if len(segment) > 1 {
+				svg.Lines = append(svg.Lines, chartShape{Path: strings.Join(segment, " "), Color: d.color(di, 0)})
+			}
+			segment = nil
+		}
+		for i, label := range spec.Labels {
+			v := d.value(i)
+			if math.IsNaN(v) {
+				flush()
+				continue
+			}
+			title := fmt.Sprintf("%s: %s", label, strconv.FormatFloat(v, 'f', -1, 64))
+			if d.Label != "" {
+				title = d.Label + ", " + title
+			}
+			if spec.Type == "bar" {
+				top, base := y(max(v, 0)), y(min(v, 0))
+				svg.Bars = append(svg.Bars, chartShape{
+					X:      left + float64(i)*group + group*0.1 + float64(di)*barWidth,
+					Y:      top,
+					Width:  barWidth,
+					Height: base - top,
+					Color:  d.color(di, i),
+					Title:  title,
+				})
+				continue
+			}
+			x := left + (float64(i)+0.5)*group
+			segment = append(segment, formatChartNumber(x)+","+formatChartNumber(y(v)))
+			svg.Points = append(svg.Points, chartShape{X: x, Y: y(v), Color: d.color(di, 0), Title: title})
+		}
+		flush()
+	}
+}
+
+func (spec *chartSpec) drawPie(svg *ChartSvg) {
+	d := spec.Datasets[0]
+	cx, cy := float64(chartWidth)/2, float64(chartPadTop+chartHeight-chartPadBottom)/2
+	radius := float64(chartHeight-chartPadTop-chartPadBottom) / 2
+	inner := 0.0
+	if spec.Type == "doughnut" {
+		inner = radius / 2
+	}
+
+	total := 0.0
+	for _, v := range d.Data {
+		if v > 0 {
+			total += v
+		}
+	}
+	svg.Legend = spec.legend(spec.Labels, func(i int) string {
+		return d.color(i, i)
+	})
+	if total == 0 {
+		return
+	}
+
+	point := func(r, angle float64) string {
+		return formatChartNumber(cx+r*math.Sin(angle)) + " " + formatChartNumber(cy-r*math.Cos(angle))
+	}
+	// slices start at the top and go clockwise, as in chart.js
+	angle := 0.0
+	for i, label := range spec.Labels {
+		v := d.value(i)
+		if math.IsNaN(v) || v <= 0 {
+			continue
+		}
+		sweep := v / total * 2 * math.Pi
+		// full circle can't be drawn with one arc
+		if sweep >= 2*math.Pi-1e-9 {
+			sweep = 2*math.Pi - 1e-4
+		}
+		large := 0
+		if sweep > math.Pi {
+			large = 1
+		}
+		r := formatChartNumber(radius)
+		path := fmt.Sprintf("M %s A %s %s 0 %d 1 %s", point(radius, angle), r, r, large, point(radius, angle+sweep))
+		if inner > 0 {
+			ir := formatChartNumber(inner)
+			path += fmt.Sprintf(" L %s A %s %s 0 %d 0 %s Z", point(inner, angle+sweep), ir, ir, large, point(inner, angle))
+		} else {
+			path += fmt.Sprintf(" L %s Z", point(0, 0))
+		}
+		svg.Slices = append(svg.Slices, chartShape{
+			Path:  path,
+			Color: d.color(i, i),
+			Title: fmt.Sprintf("%s: %s", label, strconv.FormatFloat(v, 'f', -1, 64)),
+		})
+		angle += sweep
+	}
+}
+
+// renderChart draws chart of the chart.js script as svg, so it is shown without js.
+// Scripts the renderer doesn't understand are left to the iframe.
+func (r *Renderer) renderChart(b *model.Block, script string) (string, bool) {
+	config, err := parseChartScript(script)
+	if err != nil {
+		log.Debug("chart script is not parsed, falling back to iframe", zap.String("blockId", b.GetId()), zap.Error(err))
+		return "", false
+	}
+	spec, err := chartSpecFromConfig(config)
+	if err != nil {
+		log.Debug("chart is not supported, falling back to iframe", zap.String("blockId", b.GetId()), zap.Error(err))
+		return "", false
+	}
+	content, err := utils.TemplToString(ChartTemplate(spec.makeSvg()))
+	if err != nil {
+		log.Error("failed to render chart", zap.String("blockId", b.GetId()), zap.Error(err))
+		return "", false
+	}
+	return content, true
+}
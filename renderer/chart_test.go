@@ -0,0 +1,189 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChartScript = `(async function() {
+  // rows are picked into labels and values
+  const data = [
+    { year: 2010, count: 10 },
+    { year: 2011, count: 20 },
+    { year: 2012, count: 15 },
+  ];
+
+  new Chart(
+    document.getElementById('chart'),
+    {
+      type: 'bar',
+      data: {
+        labels: data.map(row => row.year),
+        datasets: [
+          {
+            label: 'Acquisitions by year',
+            data: data.map(row => row.count)
+          }
+        ]
+      }
+    }
+  );
+})();`
+
+func TestParseChartScript(t *testing.T) {
+	t.Run("config built from rows", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(testChartScript)
+		require.NoError(t, err)
+		spec, err := chartSpecFromConfig(config)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "bar", spec.Type)
+		assert.Equal(t, []string{"2010", "2011", "2012"}, spec.Labels)
+		require.Len(t, spec.Datasets, 1)
+		assert.Equal(t, "Acquisitions by year", spec.Datasets[0].Label)
+		assert.Equal(t, []float64{10, 20, 15}, spec.Datasets[0].Data)
+	})
+	t.Run("json config", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(`{"type": "line", "data": {"labels": ["a", "b"], "datasets": [{"data": [1, -2.5]}]}}`)
+		require.NoError(t, err)
+		spec, err := chartSpecFromConfig(config)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "line", spec.Type)
+		assert.Equal(t, []float64{1, -2.5}, spec.Datasets[0].Data)
+	})
+	t.Run("config with options the renderer doesn't use", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(`new Chart(ctx, {
+			type: "pie",
+			data: { labels: ["a", "b"], datasets: [{ data: [1, 2], backgroundColor: ["red", "url(javascript:alert(1))"] }] },
+			options: { plugins: { title: { display: true, text: "Share" }, tooltip: { callbacks: { label: formatLabel } } } },
+		});`)
+		require.NoError(t, err)
+		spec, err := chartSpecFromConfig(config)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "Share", spec.Title)
+		assert.Equal(t, []string{"red", chartPalette[1]}, spec.Datasets[0].Colors)
+	})
+	t.Run("chart method is called", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(`new Chart(ctx, { type: 'bar', data: { datasets: [{ data: [1] }] } }).update();`)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "bar", config["type"])
+	})
+	t.Run("chart without config is followed by member", func(t *testing.T) {
+		// when
+		_, err := parseChartScript(`new Chart().A`)
+
+		// then
+		assert.Error(t, err)
+	})
+	t.Run("computed values are not supported", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(`new Chart(ctx, { type: 'bar', data: { datasets: [{ data: fetchData() }] } })`)
+		if err == nil {
+			_, err = chartSpecFromConfig(config)
+		}
+
+		// then
+		assert.Error(t, err)
+	})
+	t.Run("unsupported chart type", func(t *testing.T) {
+		// when
+		config, err := parseChartScript(`new Chart(ctx, { type: 'radar', data: { datasets: [{ data: [1] }] } })`)
+		require.NoError(t, err)
+		_, err = chartSpecFromConfig(config)
+
+		// then
+		assert.Error(t, err)
+	})
+}
+
+func TestRenderChart(t *testing.T) {
+	t.Run("bar chart is rendered as svg", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, testChartScript))
+
+		// then
+		assert.False(t, params.IsIframe)
+		assert.Empty(t, params.Data.Js)
+		assert.Contains(t, params.Content, `<svg class="chart"`)
+		assert.Contains(t, params.Content, `aria-label="Acquisitions by year"`)
+		assert.Equal(t, 3, strings.Count(params.Content, `<rect class="bar"`))
+		assert.Contains(t, params.Content, `<title>Acquisitions by year, 2011: 20</title>`)
+	})
+	t.Run("line chart", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+		script := `{ type: 'line', data: { labels: ['a', 'b', 'c', 'd'], datasets: [{ label: 'x', data: [1, 2, null, 4] }] } }`
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, script))
+
+		// then
+		assert.Equal(t, 1, strings.Count(params.Content, `<polyline class="line"`))
+		assert.Equal(t, 3, strings.Count(params.Content, `<circle class="point"`))
+	})
+	t.Run("doughnut chart", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+		script := `{ type: 'doughnut', data: { labels: ['a', 'b', 'c'], datasets: [{ data: [1, 0, 3] }] } }`
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, script))
+
+		// then
+		assert.Equal(t, 2, strings.Count(params.Content, `<path class="slice"`))
+		assert.Equal(t, 3, strings.Count(params.Content, `class="legendItem"`))
+	})
+	t.Run("labels are escaped", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+		script := `{ type: 'bar', data: { labels: ['<script>alert(1)</script>'], datasets: [{ data: [1] }] } }`
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, script))
+
+		// then
+		assert.NotContains(t, params.Content, "<script>")
+		assert.Contains(t, params.Content, "&lt;script&gt;")
+	})
+	t.Run("scripts which aren't understood are run in iframe", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+		script := `const values = await fetch('/data.json').then(r => r.json()); new Chart(ctx, { type: 'bar', data: values });`
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, script))
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.Equal(t, script, params.Data.Js)
+	})
+	t.Run("chart without config is run in iframe", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, `new Chart().A`))
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.Equal(t, `new Chart().A`, params.Data.Js)
+	})
+}
@@ -0,0 +1,508 @@
+package renderer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// chartscript understands the literal subset of javascript which chart configs are written in:
+// object and array literals, const declarations and "rows.map(row => row.field)".
+// Everything else is an error, and the chart is rendered by its script in the browser.
+
+type jsTokenKind int
+
+const (
+	jsPunct jsTokenKind = iota
+	jsString
+	jsNumber
+	jsIdent
+)
+
+type jsToken struct {
+	kind  jsTokenKind
+	value string
+}
+
+func tokenizeJs(src string) ([]jsToken, error) {
+	var tokens []jsToken
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+		case c == '"' || c == '\'' || c == '`':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != c; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+					switch runes[j] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[j])
+					}
+					continue
+				}
+				if c == '`' && runes[j] == '$' && j+1 < len(runes) && runes[j+1] == '{' {
+					return nil, fmt.Errorf("template literal with expressions")
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, jsToken{jsString, sb.String()})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || strings.ContainsRune(".eE_xXabcdefABCDEF", runes[j]) ||
+				((runes[j] == '-' || runes[j] == '+') && (runes[j-1] == 'e' || runes[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, jsToken{jsNumber, strings.ReplaceAll(string(runes[i:j]), "_", "")})
+			i = j
+		case unicode.IsLetter(c) || c == '_' || c == '$':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '$') {
+				j++
+			}
+			tokens = append(tokens, jsToken{jsIdent, string(runes[i:j])})
+			i = j
+		case c == '=' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, jsToken{jsPunct, "=>"})
+			i += 2
+		case c == '.' && i+2 < len(runes) && runes[i+1] == '.' && runes[i+2] == '.':
+			tokens = append(tokens, jsToken{jsPunct, "..."})
+			i += 3
+		default:
+			tokens = append(tokens, jsToken{jsPunct, string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// jsNode is an expression, evaluated after the whole script is parsed
+type jsNode interface{}
+
+type (
+	jsLiteral struct{ value any }
+	jsObject  struct {
+		keys   []string
+		values []jsNode
+	}
+	jsArray  struct{ items []jsNode }
+	jsIdentN struct{ name string }
+	jsMember struct {
+		object jsNode
+		name   string
+	}
+	jsIndex struct{ object, index jsNode }
+	jsCall  struct {
+		callee jsNode
+		args   []jsNode
+	}
+	jsArrow struct {
+		param string
+		body  jsNode
+	}
+	jsNew struct {
+		callee string
+		args   []jsNode
+	}
+)
+
+type jsParser struct {
+	tokens []jsToken
+	pos    int
+}
+
+func (p *jsParser) peek(offset int) jsToken {
+	if p.pos+offset >= len(p.tokens) {
+		return jsToken{jsPunct, ""}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *jsParser) isPunct(offset int, value string) bool {
+	t := p.peek(offset)
+	return t.kind == jsPunct && t.value == value
+}
+
+func (p *jsParser) expect(value string) error {
+	if !p.isPunct(0, value) {
+		return fmt.Errorf("expected %q, got %q", value, p.peek(0).value)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *jsParser) parseExpr() (jsNode, error) {
+	t := p.peek(0)
+
+	// arrow functions with single param
+	if t.kind == jsIdent && p.isPunct(1, "=>") {
+		p.pos += 2
+		body, err := p.parseExpr()
+		return &jsArrow{param: t.value, body: body}, err
+	}
+	if p.isPunct(0, "(") && p.peek(1).kind == jsIdent && p.isPunct(2, ")") && p.isPunct(3, "=>") {
+		param := p.peek(1).value
+		p.pos += 4
+		body, err := p.parseExpr()
+		return &jsArrow{param: param, body: body}, err
+	}
+
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isPunct(0, "."):
+			name := p.peek(1)
+			if name.kind != jsIdent {
+				return nil, fmt.Errorf("expected property name")
+			}
+			p.pos += 2
+			node = &jsMember{object: node, name: name.value}
+		case p.isPunct(0, "["):
+			p.pos++
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &jsIndex{object: node, index: index}
+		case p.isPunct(0, "("):
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			node = &jsCall{callee: node, args: args}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// parseList parses comma separated expressions after opening bracket, up to closing one
+func (p *jsParser) parseList(closing string) ([]jsNode, error) {
+	p.pos++
+	var items []jsNode
+	for !p.isPunct(0, closing) {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if !p.isPunct(0, closing) {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.pos++
+	return items, nil
+}
+
+func (p *jsParser) parsePrimary() (jsNode, error) {
+	t := p.peek(0)
+	switch {
+	case t.kind == jsString:
+		p.pos++
+		return &jsLiteral{t.value}, nil
+	case t.kind == jsNumber:
+		p.pos++
+		return parseJsNumber(t.value, 1)
+	case t.kind == jsPunct && t.value == "-" && p.peek(1).kind == jsNumber:
+		p.pos += 2
+		return parseJsNumber(p.peek(-1).value, -1)
+	case t.kind == jsPunct && t.value == "[":
+		items, err := p.parseList("]")
+		return &jsArray{items: items}, err
+	case t.kind == jsPunct && t.value == "{":
+		return p.parseObject()
+	case t.kind == jsPunct && t.value == "(":
+		p.pos++
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	case t.kind == jsIdent:
+		p.pos++
+		switch t.value {
+		case "true", "false":
+			return &jsLiteral{t.value == "true"}, nil
+		case "null", "undefined":
+			return &jsLiteral{nil}, nil
+		case "new":
+			callee := p.peek(0)
+			if callee.kind != jsIdent || !p.isPunct(1, "(") {
+				return nil, fmt.Errorf("unsupported new expression")
+			}
+			p.pos++
+			args, err := p.parseList(")")
+			return &jsNew{callee: callee.value, args: args}, err
+		case "function", "async", "await", "this":
+			return nil, fmt.Errorf("unsupported expression %q", t.value)
+		}
+		return &jsIdentN{t.value}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.value)
+}
+
+func parseJsNumber(s string, sign float64) (jsNode, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return &jsLiteral{sign * n}, nil
+	}
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return &jsLiteral{sign * float64(n)}, nil
+	}
+	return nil, fmt.Errorf("bad number %q", s)
+}
+
+func (p *jsParser) parseObject() (jsNode, error) {
+	p.pos++
+	obj := &jsObject{}
+	for !p.isPunct(0, "}") {
+		key := p.peek(0)
+		if key.kind == jsPunct {
+			return nil, fmt.Errorf("unsupported object key %q", key.value)
+		}
+		p.pos++
+		var value jsNode
+		if p.isPunct(0, ":") {
+			p.pos++
+			var err error
+			if value, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		} else if key.kind == jsIdent {
+			// shorthand property
+			value = &jsIdentN{key.value}
+		} else {
+			return nil, fmt.Errorf("expected ':'")
+		}
+		obj.keys = append(obj.keys, key.value)
+		obj.values = append(obj.values, value)
+		if !p.isPunct(0, "}") {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.pos++
+	return obj, nil
+}
+
+// jsEvalError is stored instead of values which can't be evaluated,
+// so a broken option doesn't break the whole config
+type jsEvalError struct{ err error }
+
+type jsEnv map[string]any
+
+func (env jsEnv) eval(node jsNode) (any, error) {
+	switch n := node.(type) {
+	case *jsLiteral:
+		return n.value, nil
+	case *jsArray:
+		items := make([]any, 0, len(n.items))
+		for _, item := range n.items {
+			v, err := env.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case *jsObject:
+		obj := make(map[string]any, len(n.keys))
+		for i, key := range n.keys {
+			v, err := env.eval(n.values[i])
+			if err != nil {
+				v = jsEvalError{err}
+			}
+			obj[key] = v
+		}
+		return obj, nil
+	case *jsIdentN:
+		v, ok := env[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q", n.name)
+		}
+		return v, nil
+	case *jsMember:
+		object, err := env.eval(n.object)
+		if err != nil {
+			return nil, err
+		}
+		switch o := object.(type) {
+		case map[string]any:
+			return o[n.name], nil
+		case []any:
+			if n.name == "length" {
+				return float64(len(o)), nil
+			}
+		}
+		return nil, fmt.Errorf("can't read %q", n.name)
+	case *jsIndex:
+		object, err := env.eval(n.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := env.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		switch o := object.(type) {
+		case []any:
+			if i, ok := index.(float64); ok && i >= 0 && int(i) < len(o) {
+				return o[int(i)], nil
+			}
+		case map[string]any:
+			if key, ok := index.(string); ok {
+				return o[key], nil
+			}
+		}
+		return nil, fmt.Errorf("bad index")
+	case *jsCall:
+		return env.evalCall(n)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+// evalCall supports array.map with arrow function, which charts use to pick columns from rows
+func (env jsEnv) evalCall(call *jsCall) (any, error) {
+	member, ok := call.callee.(*jsMember)
+	if !ok || member.name != "map" || len(call.args) != 1 {
+		return nil, fmt.Errorf("unsupported call")
+	}
+	arrow, ok := call.args[0].(*jsArrow)
+	if !ok {
+		return nil, fmt.Errorf("unsupported map callback")
+	}
+	object, err := env.eval(member.object)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := object.([]any)
+	if !ok {
+		return nil, fmt.Errorf("map of non-array")
+	}
+
+	inner := make(jsEnv, len(env)+1)
+	for k, v := range env {
+		inner[k] = v
+	}
+	result := make([]any, 0, len(items))
+	for _, item := range items {
+		inner[arrow.param] = item
+		v, err := inner.eval(arrow.body)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// parseChartScript finds chart config in the script: either the second argument of "new Chart(...)",
+// or the script itself if it is a config literal
+func parseChartScript(src string) (map[string]any, error) {
+	tokens, err := tokenizeJs(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &jsParser{tokens: tokens}
+	env := jsEnv{}
+
+	// config literal, possibly json
+	if p.isPunct(0, "{") {
+		node, err := p.parseExpr()
+		if err == nil && (p.pos == len(tokens) || p.isPunct(0, ";")) {
+			return evalChartConfig(env, node)
+		}
+		p.pos = 0
+	}
+
+	for ; p.pos < len(tokens); p.pos++ {
+		t := p.peek(0)
+		if t.kind != jsIdent {
+			continue
+		}
+		switch {
+		case (t.value == "const" || t.value == "let" || t.value == "var") && p.peek(1).kind == jsIdent && p.isPunct(2, "="):
+			name := p.peek(1).value
+			start := p.pos
+			p.pos += 3
+			if node, err := p.parseExpr(); err == nil {
+				if v, err := env.eval(node); err == nil {
+					env[name] = v
+				}
+			}
+			// step back, the loop steps over the last token
+			p.pos = max(start, p.pos-1)
+		case t.value == "new" && p.peek(1).kind == jsIdent && p.peek(1).value == "Chart":
+			node, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			chart, ok := chartNew(node)
+			if !ok || len(chart.args) < 2 {
+				return nil, fmt.Errorf("chart has no config")
+			}
+			return evalChartConfig(env, chart.args[1])
+		}
+	}
+	return nil, fmt.Errorf("chart config is not found")
+}
+
+// chartNew returns "new Chart(...)" expression, which may be followed by calls, e.g. new Chart(ctx, config).update()
+func chartNew(node jsNode) (*jsNew, bool) {
+	for {
+		switch n := node.(type) {
+		case *jsNew:
+			return n, true
+		case *jsMember:
+			node = n.object
+		case *jsCall:
+			node = n.callee
+		case *jsIndex:
+			node = n.object
+		default:
+			return nil, false
+		}
+	}
+}
+
+func evalChartConfig(env jsEnv, node jsNode) (map[string]any, error) {
+	v, err := env.eval(node)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("chart config is not an object")
+	}
+	return config, nil
+}
@@ -21,6 +21,7 @@ type CoverResizeParams struct {
 type CoverRenderParams struct {
 	Id                string
 	Src               string
+	Srcset            string
 	Classes           string
 	CoverType         CoverType
 	ResizeParams      CoverResizeParams
@@ -93,10 +94,13 @@ func (r *Renderer) getCoverParams(fields *types.Struct, asImage bool, withAuthor
 			}
 		}
 
-		params.Src = src
 		if asImage {
+			params.Src = src
+			params.Srcset = r.imageSrcset(r.imageInfo(coverId, src))
 			params.CoverTemplate = CoverImageTemplate(params)
 		} else {
+			// background covers of cards are never wider than page content
+			params.Src = r.smallImageSrc(coverId, src, contentWidth)
 			params.CoverTemplate = CoverDefaultTemplate(params)
 		}
 
@@ -0,0 +1,296 @@
+package renderer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// DefaultDerivativeWidths are widths of image derivatives if none are configured
+var DefaultDerivativeWidths = []int{320, 640, 1280}
+
+const derivativeJpegQuality = 82
+
+// DefaultDerivativeMaxPixels limits size of images which are decoded for derivatives,
+// decoded image takes 4 bytes per pixel and more while it is converted
+const DefaultDerivativeMaxPixels = 40_000_000
+
+// ImageDeriver writes resized copies of package images during export.
+// Its Derivatives method is used as RenderConfig.ImageDerivatives.
+// Derivatives are named by content hash of the original, so unchanged images
+// are not resized again, neither in one export nor in the next ones into the same Dir.
+type ImageDeriver struct {
+	// directory derivatives are written to
+	Dir string
+	// url of Dir on the published page
+	Url    string
+	Widths []int
+	// bigger images have no derivatives
+	MaxPixels int
+
+	mu     sync.Mutex
+	byHash map[string][]ImageDerivative
+}
+
+func NewImageDeriver(dir, url string, widths []int) *ImageDeriver {
+	if len(widths) == 0 {
+		widths = DefaultDerivativeWidths
+	}
+	widths = slices.Clone(widths)
+	slices.Sort(widths)
+	return &ImageDeriver{
+		Dir:       dir,
+		Url:       strings.TrimSuffix(url, "/"),
+		Widths:    slices.Compact(widths),
+		MaxPixels: DefaultDerivativeMaxPixels,
+		byHash:    make(map[string][]ImageDerivative),
+	}
+}
+
+// Derivatives resizes local image to configured widths smaller than the original.
+// Only jpeg and png are resized, other formats have no derivatives.
+func (d *ImageDeriver) Derivatives(img ImageInfo) []ImageDerivative {
+	if img.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(img.Path)
+	if err != nil {
+		log.Warn("failed to read image for derivatives", zap.String("path", img.Path), zap.Error(err))
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:16])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if derivatives, ok := d.byHash[hash]; ok {
+		return derivatives
+	}
+	derivatives, err := d.derive(hash, data)
+	if err != nil {
+		log.Warn("failed to make image derivatives", zap.String("path", img.Path), zap.Error(err))
+	}
+	d.byHash[hash] = derivatives
+	return derivatives
+}
+
+func (d *ImageDeriver) derive(hash string, data []byte) ([]ImageDerivative, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// not an image we can decode
+		return nil, nil
+	}
+	var ext string
+	orientation := 1
+	switch format {
+	case "jpeg":
+		ext = "jpg"
+		orientation = jpegOrientation(data)
+	case "png":
+		ext = "png"
+	default:
+		// gifs would lose animation
+		return nil, nil
+	}
+	if config.Width*config.Height > d.MaxPixels {
+		return nil, fmt.Errorf("image is too big: %dx%d", config.Width, config.Height)
+	}
+	// browsers show jpeg rotated by exif, derivatives have no exif, so their pixels are rotated
+	width, height := config.Width, config.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	var (
+		src         image.Image
+		derivatives []ImageDerivative
+	)
+	for _, derivativeWidth := range d.Widths {
+		if derivativeWidth >= width {
+			break
+		}
+		name := fmt.Sprintf("%s-%d.%s", hash, derivativeWidth, ext)
+		path := filepath.Join(d.Dir, name)
+		if _, err := os.Stat(path); err != nil {
+			if src == nil {
+				if src, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+					return nil, nil
+				}
+				src = orientImage(src, orientation)
+			}
+			derivativeHeight := max(1, height*derivativeWidth/width)
+			if err := writeDerivative(path, resizeImage(src, derivativeWidth, derivativeHeight), format); err != nil {
+				return derivatives, err
+			}
+		}
+		derivatives = append(derivatives, ImageDerivative{Src: d.Url + "/" + name, Width: derivativeWidth})
+	}
+	return derivatives, nil
+}
+
+// jpegOrientation reads exif orientation tag of jpeg, 1 if there is none
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data) && data[pos] == 0xFF; {
+		marker := data[pos+1]
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		// exif is before image data
+		if marker == 0xDA || size < 2 || pos+2+size > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads orientation tag from the first IFD of tiff structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// orientImage flips and rotates image the way exif orientation says it is displayed
+func orientImage(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := w, h
+	if orientation >= 5 {
+		dstWidth, dstHeight = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// writeDerivative writes into temporary file first, so interrupted export doesn't leave broken images in cache
+func writeDerivative(path string, img image.Image, format string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".derivative-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	// temporary files are private, derivatives are published
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+
+	if format == "jpeg" {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: derivativeJpegQuality})
+	} else {
+		err = png.Encode(f, img)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// resizeImage downscales image by averaging source pixels covered by each result pixel
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, src, bounds.Min, draw.Src)
+	}
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := rgba.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					pix := rgba.Pix[offset : offset+4]
+					r, g, b, a = r+uint64(pix[0]), g+uint64(pix[1]), b+uint64(pix[2]), a+uint64(pix[3])
+					offset += 4
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
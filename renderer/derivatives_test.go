@@ -0,0 +1,176 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageDeriver(t *testing.T) {
+	t.Run("derivatives smaller than the original", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		writeTestPng(t, dir, "image.png", 100, 50)
+		d := NewImageDeriver(filepath.Join(dir, "out"), "/img/", []int{80, 40, 200})
+
+		// when
+		derivatives := d.Derivatives(ImageInfo{Path: filepath.Join(dir, "image.png")})
+
+		// then
+		require.Len(t, derivatives, 2)
+		assert.Equal(t, 40, derivatives[0].Width)
+		assert.Equal(t, 80, derivatives[1].Width)
+		for _, derivative := range derivatives {
+			assert.Regexp(t, `^/img/[0-9a-f]{32}-\d+\.png$`, derivative.Src)
+			width, height, err := readImageSize(filepath.Join(dir, "out", filepath.Base(derivative.Src)))
+			require.NoError(t, err)
+			assert.Equal(t, derivative.Width, width)
+			assert.Equal(t, derivative.Width/2, height)
+		}
+	})
+	t.Run("derivatives of the same content are reused", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out")
+		writeTestPng(t, dir, "a.png", 100, 50)
+		writeTestPng(t, dir, "b.png", 100, 50)
+		first := NewImageDeriver(out, "/img", []int{40}).Derivatives(ImageInfo{Path: filepath.Join(dir, "a.png")})
+		require.Len(t, first, 1)
+		cached := filepath.Join(out, filepath.Base(first[0].Src))
+		require.NoError(t, os.WriteFile(cached, []byte("cached"), 0o644))
+
+		// when
+		second := NewImageDeriver(out, "/img", []int{40}).Derivatives(ImageInfo{Path: filepath.Join(dir, "b.png")})
+
+		// then
+		assert.Equal(t, first, second)
+		data, err := os.ReadFile(cached)
+		require.NoError(t, err)
+		assert.Equal(t, "cached", string(data))
+	})
+	t.Run("formats which can't be decoded are skipped", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "image.heic"), []byte("not an image"), 0o644))
+		d := NewImageDeriver(filepath.Join(dir, "out"), "/img", nil)
+
+		// when
+		derivatives := d.Derivatives(ImageInfo{Path: filepath.Join(dir, "image.heic")})
+
+		// then
+		assert.Nil(t, derivatives)
+		assert.NoDirExists(t, filepath.Join(dir, "out"))
+	})
+	t.Run("images bigger than pixel limit are skipped", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		writeTestPng(t, dir, "image.png", 100, 50)
+		d := NewImageDeriver(filepath.Join(dir, "out"), "/img", []int{40})
+		d.MaxPixels = 1000
+
+		// when
+		derivatives := d.Derivatives(ImageInfo{Path: filepath.Join(dir, "image.png")})
+
+		// then
+		assert.Nil(t, derivatives)
+		assert.NoDirExists(t, filepath.Join(dir, "out"))
+	})
+	t.Run("jpeg is rotated by exif orientation", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		writeTestJpeg(t, filepath.Join(dir, "image.jpg"), 6)
+		d := NewImageDeriver(filepath.Join(dir, "out"), "/img", []int{10, 30})
+
+		// when
+		derivatives := d.Derivatives(ImageInfo{Path: filepath.Join(dir, "image.jpg")})
+
+		// then
+		require.Len(t, derivatives, 1)
+		f, err := os.Open(filepath.Join(dir, "out", filepath.Base(derivatives[0].Src)))
+		require.NoError(t, err)
+		defer f.Close()
+		img, err := jpeg.Decode(f)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 10, 20), img.Bounds())
+		// left half of the original is red, it is on top after rotating clockwise
+		top, _, _, _ := img.At(5, 2).RGBA()
+		bottom, _, _, _ := img.At(5, 17).RGBA()
+		assert.Greater(t, top, uint32(0xC000))
+		assert.Less(t, bottom, uint32(0x4000))
+	})
+	t.Run("remote images are skipped", func(t *testing.T) {
+		d := NewImageDeriver(t.TempDir(), "/img", nil)
+		assert.Nil(t, d.Derivatives(ImageInfo{Src: "https://example.com/image.png"}))
+	})
+}
+
+// writeTestJpeg writes 40x20 jpeg, red on the left and blue on the right, with exif orientation
+func writeTestJpeg(t *testing.T, path string, orientation uint16) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+
+	// big-endian tiff with one IFD entry: orientation, short, count 1
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0, 0, 0, 0, 0, 0, 0}
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	data := append(append([]byte{0xFF, 0xD8}, segment...), buf.Bytes()[2:]...)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestJpegOrientation(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	writeTestJpeg(t, filepath.Join(dir, "image.jpg"), 8)
+	data, err := os.ReadFile(filepath.Join(dir, "image.jpg"))
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, 8, jpegOrientation(data))
+	assert.Equal(t, 1, jpegOrientation(data[:10]))
+	assert.Equal(t, 1, jpegOrientation([]byte("not a jpeg")))
+}
+
+func TestOrientImage(t *testing.T) {
+	// given
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	// then
+	for orientation, corner := range map[int]image.Point{1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1}, 5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2}} {
+		dst := orientImage(src, orientation)
+		r, _, _, _ := dst.At(corner.X, corner.Y).RGBA()
+		assert.Equal(t, uint32(0xFFFF), r, "orientation %d", orientation)
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	// given
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{R: 200, A: 255})
+	src.Set(1, 0, color.RGBA{R: 100, A: 255})
+	src.Set(0, 1, color.RGBA{B: 40, A: 255})
+	src.Set(1, 1, color.RGBA{B: 80, A: 255})
+
+	// when
+	dst := resizeImage(src, 1, 1)
+
+	// then
+	assert.Equal(t, color.RGBA{R: 75, B: 30, A: 255}, dst.At(0, 0))
+}
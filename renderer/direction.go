@@ -0,0 +1,171 @@
+package renderer
+
+import (
+	"unicode"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+)
+
+const (
+	dirLtr  = "ltr"
+	dirRtl  = "rtl"
+	dirAuto = "auto"
+)
+
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko,
+}
+
+// letters which are used in Arabic script only by these languages
+var arabicScriptLangs = []struct {
+	lang    string
+	letters string
+}{
+	{"ur", "ٹڈڑںےۓھ"},
+	{"fa", "پچژگکی"},
+}
+
+var ogLocales = map[string]string{
+	"en": "en_US",
+	"ar": "ar_AR",
+	"he": "he_IL",
+	"fa": "fa_IR",
+	"ur": "ur_PK",
+}
+
+// directionStats counts letters with strong direction
+type directionStats struct {
+	ltr, rtl int
+	hebrew   int
+	arabic   map[string]int
+}
+
+func (s *directionStats) add(text string) {
+	for _, c := range text {
+		if !unicode.IsLetter(c) {
+			continue
+		}
+		switch {
+		case unicode.In(c, rtlScripts...):
+			s.rtl++
+			if unicode.Is(unicode.Hebrew, c) {
+				s.hebrew++
+			}
+			if unicode.Is(unicode.Arabic, c) {
+				if s.arabic == nil {
+					s.arabic = make(map[string]int)
+				}
+				s.arabic["ar"]++
+				for _, l := range arabicScriptLangs {
+					for _, letter := range l.letters {
+						if c == letter {
+							s.arabic[l.lang]++
+						}
+					}
+				}
+			}
+		default:
+			s.ltr++
+		}
+	}
+}
+
+// direction is the dominant direction, empty if there are no letters
+func (s *directionStats) direction() string {
+	switch {
+	case s.rtl == 0 && s.ltr == 0:
+		return ""
+	case s.rtl > s.ltr:
+		return dirRtl
+	default:
+		return dirLtr
+	}
+}
+
+// lang guesses language of rtl text by its script, empty for ltr text
+// and for scripts other than Hebrew and Arabic
+func (s *directionStats) lang() string {
+	if s.direction() != dirRtl || s.hebrew == 0 && s.arabic["ar"] == 0 {
+		return ""
+	}
+	if s.hebrew >= s.arabic["ar"] {
+		return "he"
+	}
+	for _, l := range arabicScriptLangs {
+		if s.arabic[l.lang] > 0 {
+			return l.lang
+		}
+	}
+	return "ar"
+}
+
+// hydrateDirection detects page direction and language from its text,
+// and mirrors left and right alignments for rtl pages
+func (r *Renderer) hydrateDirection() {
+	stats := &directionStats{}
+	stats.add(getRelationField(r.Sp.GetSnapshot().GetData().GetDetails(), "name", relationToString))
+	for _, b := range r.BlocksById {
+		stats.add(b.GetText().GetText())
+	}
+
+	r.Direction = stats.direction()
+	if r.Direction == "" {
+		r.Direction = dirLtr
+	}
+	r.Lang = r.Config.Lang
+	if r.Lang == "" {
+		r.Lang = stats.lang()
+	}
+	if r.Lang == "" {
+		r.Lang = "en"
+	}
+
+	if r.Direction != dirRtl {
+		return
+	}
+	r.LayoutAlign = mirrorAlign(r.LayoutAlign)
+	for _, b := range r.BlocksById {
+		b.Align = model.BlockAlign(mirrorAlign(int64(b.Align)))
+	}
+}
+
+// mirrorAlign swaps left and right, so "start" alignment stays at the start of rtl lines
+func mirrorAlign(align int64) int64 {
+	switch model.BlockAlign(align) {
+	case model.Block_AlignLeft:
+		return int64(model.Block_AlignRight)
+	case model.Block_AlignRight:
+		return int64(model.Block_AlignLeft)
+	}
+	return align
+}
+
+// blockDirection returns dir for the text, if it differs from the page one.
+// Text with both directions gets "auto" when its dominant direction matches the page,
+// so the browser picks direction of every paragraph by its first letter.
+func (r *Renderer) blockDirection(text string) string {
+	stats := &directionStats{}
+	stats.add(text)
+
+	dir := stats.direction()
+	pageDir := r.Direction
+	if pageDir == "" {
+		pageDir = dirLtr
+	}
+	switch {
+	case dir == "":
+		return ""
+	case dir != pageDir:
+		return dir
+	case stats.ltr > 0 && stats.rtl > 0:
+		return dirAuto
+	}
+	return ""
+}
+
+func (r *Renderer) ogLocale() string {
+	if locale, ok := ogLocales[r.Lang]; ok {
+		return locale
+	}
+	return ogLocales["en"]
+}
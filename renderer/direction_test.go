@@ -0,0 +1,151 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectionStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		direction string
+		lang      string
+	}{
+		{"empty", "", "", ""},
+		{"digits only", "123 - 456", "", ""},
+		{"english", "Hello world", dirLtr, ""},
+		{"hebrew", "שלום עולם", dirRtl, "he"},
+		{"arabic", "مرحبا بالعالم", dirRtl, "ar"},
+		{"persian", "سلام دنیا، چطوری؟", dirRtl, "fa"},
+		{"urdu", "یہ ایک کتاب ہے", dirRtl, "ur"},
+		{"syriac", "ܫܠܡܐ ܥܠܡܐ", dirRtl, ""},
+		{"thaana", "ދިވެހިރާއްޖެ", dirRtl, ""},
+		{"nko", "ߒߞߏ ߞߊ߲", dirRtl, ""},
+		{"mostly arabic with english", "مرحبا بالعالم Anytype", dirRtl, "ar"},
+		{"mostly english with arabic", "Anytype is called مرحبا here", dirLtr, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &directionStats{}
+			stats.add(tt.text)
+
+			assert.Equal(t, tt.direction, stats.direction())
+			assert.Equal(t, tt.lang, stats.lang())
+		})
+	}
+}
+
+func TestBlockDirection(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageDir  string
+		text     string
+		expected string
+	}{
+		{"ltr text on ltr page", dirLtr, "Hello", ""},
+		{"rtl text on ltr page", dirLtr, "שלום", dirRtl},
+		{"ltr text on rtl page", dirRtl, "Hello", dirLtr},
+		{"mixed text on ltr page", dirLtr, "Hello שלום world", dirAuto},
+		{"no letters", dirRtl, "42", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewTestRenderer()
+			r.Direction = tt.pageDir
+
+			assert.Equal(t, tt.expected, r.blockDirection(tt.text))
+		})
+	}
+}
+
+func TestHydrateDirection(t *testing.T) {
+	newRenderer := func(config RenderConfig, name string, blocks ...*model.Block) *TestRenderer {
+		blocksById := make(map[string]*model.Block)
+		for _, b := range blocks {
+			blocksById[b.Id] = b
+		}
+		r := NewTestRenderer(
+			WithConfig(config),
+			WithBlocksById(blocksById),
+			WithRootSnapshot(&pb.SnapshotWithType{Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+				Details: &types.Struct{Fields: map[string]*types.Value{"name": pbtypes.String(name)}},
+			}}}),
+		)
+		return r
+	}
+
+	t.Run("rtl page mirrors alignment", func(t *testing.T) {
+		// given
+		left := textBlock("left", model.BlockContentText_Paragraph, "مرحبا بالعالم")
+		right := textBlock("right", model.BlockContentText_Paragraph, "مرحبا")
+		right.Align = model.Block_AlignRight
+		center := textBlock("center", model.BlockContentText_Paragraph, "مرحبا")
+		center.Align = model.Block_AlignCenter
+		r := newRenderer(RenderConfig{}, "صفحة", left, right, center)
+
+		// when
+		r.hydrateDirection()
+
+		// then
+		assert.Equal(t, dirRtl, r.Direction)
+		assert.Equal(t, "ar", r.Lang)
+		assert.Equal(t, "ar_AR", r.ogLocale())
+		assert.Equal(t, int64(model.Block_AlignRight), r.LayoutAlign)
+		assert.Equal(t, model.Block_AlignRight, left.Align)
+		assert.Equal(t, model.Block_AlignLeft, right.Align)
+		assert.Equal(t, model.Block_AlignCenter, center.Align)
+	})
+	t.Run("ltr page keeps alignment", func(t *testing.T) {
+		// given
+		block := textBlock("block", model.BlockContentText_Paragraph, "Hello, שלום")
+		r := newRenderer(RenderConfig{}, "Page", block)
+
+		// when
+		r.hydrateDirection()
+
+		// then
+		assert.Equal(t, dirLtr, r.Direction)
+		assert.Equal(t, "en", r.Lang)
+		assert.Equal(t, model.Block_AlignLeft, block.Align)
+	})
+	t.Run("rtl script of unknown language", func(t *testing.T) {
+		// given
+		r := newRenderer(RenderConfig{}, "ܫܠܡܐ ܥܠܡܐ")
+
+		// when
+		r.hydrateDirection()
+
+		// then
+		assert.Equal(t, dirRtl, r.Direction)
+		assert.Equal(t, "en", r.Lang)
+	})
+	t.Run("configured lang", func(t *testing.T) {
+		// given
+		r := newRenderer(RenderConfig{Lang: "yi"}, "שלום")
+
+		// when
+		r.hydrateDirection()
+
+		// then
+		assert.Equal(t, dirRtl, r.Direction)
+		assert.Equal(t, "yi", r.Lang)
+	})
+}
+
+func TestTextBlockDir(t *testing.T) {
+	// given
+	r := NewTestRenderer()
+	r.Direction = dirLtr
+
+	// when
+	actual := r.makeTextBlockParams(textBlock("block", model.BlockContentText_Paragraph, "שלום עולם"))
+
+	// then
+	assert.Equal(t, dirRtl, actual.Dir)
+}
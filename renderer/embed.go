@@ -5,14 +5,17 @@ import (
 	"compress/zlib"
 	"encoding/base64"
 	"fmt"
+	"html"
 	"net/url"
-	"reflect"
 	"regexp"
 	"strings"
 
 	"github.com/a-h/templ"
 	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
-	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer/htmlsanitizer"
+	"github.com/anyproto/anytype-publish-renderer/utils"
 )
 
 type EmbedIframeData struct {
@@ -34,28 +37,275 @@ type EmbedRenderParams struct {
 	Data     EmbedIframeData
 	IsIframe bool
 	Sandbox  string
+	// click-to-load placeholder, nil if embed is loaded right away
+	Facade *EmbedFacade
+	// what was removed from embed html, nil if it was not sanitized
+	SanitizeReport *htmlsanitizer.Report
 }
 
 type JsSVGString struct {
 	Content string `json:"content,omitempty"`
 }
 
+var iframeAttributes = []string{
+	"src", "width", "height", "frameborder", "scrolling", "allow", "allowfullscreen",
+	"allowtransparency", "title", "loading", "referrerpolicy", "id", "name",
+}
+
+// text formatting which may come along with embed codes
+var embedTextTags = []string{"div", "p", "span", "a", "br", "b", "i", "em", "strong", "blockquote"}
+
+func embedTextAttributes() map[string][]string {
+	return map[string][]string{
+		"*": {"class", "style", "dir", "lang"},
+		"a": {"href", "target", "rel", "title"},
+	}
+}
+
+func iframePolicy(hosts ...string) *htmlsanitizer.Policy {
+	attributes := embedTextAttributes()
+	attributes["iframe"] = iframeAttributes
+	return &htmlsanitizer.Policy{
+		Tags:        append([]string{"iframe"}, embedTextTags...),
+		Attributes:  attributes,
+		IframeHosts: hosts,
+	}
+}
+
+// policy for embed codes which are rendered by provider script from markup
+func widgetPolicy(attributes []string, tags ...string) *htmlsanitizer.Policy {
+	policyAttributes := embedTextAttributes()
+	policyAttributes["*"] = append(policyAttributes["*"], attributes...)
+	return &htmlsanitizer.Policy{
+		Tags:       append(tags, embedTextTags...),
+		Attributes: policyAttributes,
+	}
+}
+
 var iframeParams = `frameborder="0" scrolling="no" allowfullscreen`
-var domains = map[model.BlockContentLatexProcessor][]string{
-	model.BlockContentLatex_Youtube:       {`youtube\.com`, `youtu\.be`},
-	model.BlockContentLatex_Vimeo:         {`vimeo\.com`},
-	model.BlockContentLatex_GoogleMaps:    {`google\.[^/]+/maps`},
-	model.BlockContentLatex_Miro:          {`miro\.com`},
-	model.BlockContentLatex_Figma:         {`figma\.com`},
-	model.BlockContentLatex_OpenStreetMap: {`openstreetmap\.org/\#map`},
-	model.BlockContentLatex_Telegram:      {`t\.me`},
-	model.BlockContentLatex_Codepen:       {`codepen\.io`},
-	model.BlockContentLatex_Bilibili:      {`bilibili\.com`, `b23\.tv`},
-	model.BlockContentLatex_Kroki:         {`kroki\.io`},
-	model.BlockContentLatex_GithubGist:    {`gist\.github\.com`},
-	model.BlockContentLatex_Sketchfab:     {`sketchfab\.com`},
+
+func iframeHtml(attributes string) func(string) string {
+	return func(content string) string {
+		if attributes == "" {
+			return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, html.EscapeString(content), iframeParams)
+		}
+		return fmt.Sprintf(`<iframe src="%s" %s %s></iframe>`, html.EscapeString(content), iframeParams, attributes)
+	}
 }
 
+func builtinEmbedProcessors() []EmbedProcessor {
+	return []EmbedProcessor{
+		&youtubeProcessor{BasicEmbedProcessor{
+			Id:      model.BlockContentLatex_Youtube,
+			Domains: []string{`youtube\.com`, `youtu\.be`},
+			EmbedUrlFunc: func(inputUrl string) string {
+				return fmt.Sprintf("https://www.youtube.com/embed/%s", extractYoutubeId(inputUrl))
+			},
+			HtmlFunc: youtubeHtml,
+			EmbedOptions: EmbedOptions{
+				Sandbox:       []string{"allow-presentation"},
+				AllowEmbedUrl: true,
+				Policy:        iframePolicy("youtube.com", "youtube-nocookie.com"),
+			},
+		}},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Vimeo,
+			Domains:      []string{`vimeo\.com`},
+			EmbedUrlFunc: vimeoEmbedUrl,
+			HtmlFunc:     iframeHtml(`allow="autoplay; fullscreen; picture-in-picture"`),
+			EmbedOptions: EmbedOptions{
+				Sandbox:       []string{"allow-presentation"},
+				AllowEmbedUrl: true,
+				Policy:        iframePolicy("vimeo.com"),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Soundcloud,
+			EmbedOptions: EmbedOptions{Policy: iframePolicy("soundcloud.com")},
+		},
+		newGoogleMapsProcessor(""),
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Miro,
+			Domains:      []string{`miro\.com`},
+			EmbedUrlFunc: func(inputUrl string) string { return strings.Split(inputUrl, "?")[0] + "/live-embed/" },
+			HtmlFunc:     iframeHtml(`allow="fullscreen; clipboard-read; clipboard-write"`),
+			EmbedOptions: EmbedOptions{AllowEmbedUrl: true, Policy: iframePolicy("miro.com")},
+		},
+		&BasicEmbedProcessor{
+			Id:      model.BlockContentLatex_Figma,
+			Domains: []string{`figma\.com`},
+			EmbedUrlFunc: func(inputUrl string) string {
+				return fmt.Sprintf("https://www.figma.com/embed?embed_host=share&url=%s", url.QueryEscape(inputUrl))
+			},
+			HtmlFunc:     iframeHtml(""),
+			EmbedOptions: EmbedOptions{AllowEmbedUrl: true, Policy: iframePolicy("figma.com")},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Twitter,
+			Domains:      []string{`twitter\.com`, `x\.com`},
+			EmbedUrlFunc: twitterEmbedUrl,
+			HtmlFunc:     twitterHtml,
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				ProviderUrlOnly:   true,
+				AllowIframeResize: true,
+				InsertBeforeLoad:  true,
+				UseRootHeight:     true,
+				Policy:            widgetPolicy([]string{"data-*"}),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_OpenStreetMap,
+			Domains:      []string{`openstreetmap\.org/\#map`},
+			EmbedUrlFunc: parseOpenStreetMapUrl,
+			HtmlFunc:     iframeHtml(""),
+			EmbedOptions: EmbedOptions{AllowEmbedUrl: true, Policy: iframePolicy("openstreetmap.org")},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Reddit,
+			Domains:      []string{`reddit\.com`},
+			EmbedUrlFunc: redditEmbedUrl,
+			HtmlFunc:     redditHtml,
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				ProviderUrlOnly:   true,
+				AllowIframeResize: true,
+				InsertBeforeLoad:  true,
+				Policy:            widgetPolicy([]string{"data-*"}),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Facebook,
+			Domains:      []string{`facebook\.com`, `fb\.watch`},
+			EmbedUrlFunc: stripUrlQuery,
+			HtmlFunc:     facebookHtml,
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				ProviderUrlOnly:   true,
+				AllowIframeResize: true,
+				Policy:            iframePolicy("facebook.com"),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Instagram,
+			Domains:      []string{`instagram\.com`, `instagr\.am`},
+			EmbedUrlFunc: instagramEmbedUrl,
+			HtmlFunc:     instagramHtml,
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				ProviderUrlOnly:   true,
+				AllowIframeResize: true,
+				InsertBeforeLoad:  true,
+				UseRootHeight:     true,
+				Policy:            widgetPolicy([]string{"data-*", "viewBox", "fill", "d", "transform", "xmlns", "width", "height", "version"}, "svg", "g", "path"),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:      model.BlockContentLatex_Telegram,
+			Domains: []string{`t\.me`},
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				AllowIframeResize: true,
+				UseRootHeight:     true,
+				Policy: &htmlsanitizer.Policy{
+					Tags:          []string{"script"},
+					Attributes:    map[string][]string{"script": {"async", "data-*"}},
+					ScriptSources: []string{"telegram.org"},
+				},
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_GithubGist,
+			Domains:      []string{`gist\.github\.com`},
+			EmbedUrlFunc: func(inputUrl string) string { return strings.Split(inputUrl, "#")[0] },
+			HtmlFunc: func(content string) string {
+				return fmt.Sprintf(`<script src="%s.js"></script>`, html.EscapeString(content))
+			},
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				AllowIframeResize: true,
+				UseRootHeight:     true,
+				Policy: &htmlsanitizer.Policy{
+					Tags:          []string{"script"},
+					ScriptSources: []string{"gist.github.com"},
+				},
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:       model.BlockContentLatex_Codepen,
+			Domains:  []string{`codepen\.io`},
+			HtmlFunc: codepenHtml,
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				AllowIframeResize: true,
+				InsertBeforeLoad:  true,
+				UseRootHeight:     true,
+				Policy:            widgetPolicy([]string{"data-*"}),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Bilibili,
+			Domains:      []string{`bilibili\.com`, `b23\.tv`},
+			EmbedUrlFunc: parseBilibiliUrl,
+			HtmlFunc:     iframeHtml(""),
+			PrepareFunc:  prepareBilibili,
+			EmbedOptions: EmbedOptions{
+				Sandbox:       []string{"allow-presentation"},
+				AllowEmbedUrl: true,
+				Policy:        iframePolicy("bilibili.com", "bilibili.tv"),
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Excalidraw,
+			EmbedOptions: EmbedOptions{Policy: iframePolicy("excalidraw.com")},
+		},
+		newKrokiProcessor(DefaultKrokiUrl),
+		&BasicEmbedProcessor{
+			Id:      model.BlockContentLatex_Sketchfab,
+			Domains: []string{`sketchfab\.com`},
+			EmbedUrlFunc: func(inputUrl string) string {
+				return fmt.Sprintf("https://sketchfab.com/models/%s/embed", extractSketchfabId(inputUrl))
+			},
+			HtmlFunc:     iframeHtml(""),
+			PrepareFunc:  prepareSketchfab,
+			EmbedOptions: EmbedOptions{AllowEmbedUrl: true, Policy: iframePolicy("sketchfab.com")},
+		},
+		&BasicEmbedProcessor{
+			Id: model.BlockContentLatex_Image,
+			HtmlFunc: func(content string) string {
+				return fmt.Sprintf(`<img src="%s" />`, html.EscapeString(content))
+			},
+			EmbedOptions: EmbedOptions{
+				AllowEmbedUrl:     true,
+				AllowIframeResize: true,
+				Policy: &htmlsanitizer.Policy{
+					Tags:       []string{"img"},
+					Attributes: map[string][]string{"img": {"src", "alt", "width", "height"}},
+				},
+			},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Drawio,
+			EmbedOptions: EmbedOptions{Policy: iframePolicy("diagrams.net", "draw.io")},
+		},
+		&BasicEmbedProcessor{
+			Id:           model.BlockContentLatex_Spotify,
+			EmbedOptions: EmbedOptions{Policy: iframePolicy("spotify.com")},
+		},
+		&BasicEmbedProcessor{
+			Id: model.BlockContentLatex_Chart,
+			EmbedOptions: EmbedOptions{
+				AllowJs:           true,
+				AllowIframeResize: true,
+				UseRootHeight:     true,
+			},
+		},
+	}
+}
+
+// processors without own policy get text formatting only
+var defaultEmbedPolicy = widgetPolicy(nil)
+
 func (r *Renderer) MakeEmbedRenderParams(b *model.Block) *EmbedRenderParams {
 	id := b.GetId()
 	latex := b.GetLatex()
@@ -68,159 +318,123 @@ func (r *Renderer) MakeEmbedRenderParams(b *model.Block) *EmbedRenderParams {
 	data := EmbedIframeData{}
 	isIframe := false
 	sandbox := []string{}
+	var report *htmlsanitizer.Report
+	var facade *EmbedFacade
 
 	switch processor {
 	default:
-		isIframe = true
-		allowIframeResize := allowIframeResize(processor)
-		allowScript := false
-		sandbox = append(sandbox, "allow-scripts", "allow-same-origin", "allow-popups")
-
-		if allowPresentation(processor) {
-			sandbox = append(sandbox, "allow-presentation")
+		embedProcessor := r.embedRegistry().ForBlock(processor, text)
+		provider := embedProcessor.Name()
+		if r.Config.Embeds.IsDisabled(provider) {
+			return r.makeDisabledEmbedParams(b, provider, classes)
 		}
 
-		if allowPopup(processor) {
-			sandbox = append(sandbox, "allow-popups")
-		}
+		isIframe = true
+		options := r.Config.Embeds.embedOptions(embedProcessor)
+		sandbox = r.Config.Embeds.embedSandbox(provider, options)
 
-		data.AllowIframeResize = allowIframeResize
-		data.InsertBeforeLoad = insertBeforeLoad(processor)
-		data.UseRootHeight = useRootHeight(processor)
+		data.AllowIframeResize = options.AllowIframeResize
+		data.InsertBeforeLoad = options.InsertBeforeLoad
+		data.UseRootHeight = options.UseRootHeight
 		data.Align = align
 		data.Processor = processor
 		data.ClassName = embedClass
 		data.BlockId = id
 
-		// Fix Bilibili schemeless URLs and autoplay
-		if processor == model.BlockContentLatex_Bilibili {
-			reSrc := regexp.MustCompile(`src="(//player[^"]+)"`)
-			text = reSrc.ReplaceAllString(text, `src="https:$1"`)
+		text = embedProcessor.Prepare(b, text)
 
-			reAutoplay := regexp.MustCompile(`autoplay=`)
-			if !reAutoplay.MatchString(text) {
-				reInsertAutoplay := regexp.MustCompile(`(src="[^"]+)`)
-				text = reInsertAutoplay.ReplaceAllString(text, `$1&autoplay=0`)
+		if processor == model.BlockContentLatex_Kroki && r.Config.Embeds.InlineKroki {
+			if diagram, ok := r.inlineKroki(b, text); ok {
+				return &EmbedRenderParams{Id: b.Id, Classes: classes, Content: diagram}
 			}
 		}
 
-		// Convert Kroki code into an SVG URL
-		if processor == model.BlockContentLatex_Kroki && !strings.HasPrefix(text, "https://kroki.io") {
-			compressed, err := compressAndEncode(text)
-
-			if err == nil {
-				typeId := pbtypes.GetString(b.GetFields(), "type")
-				text = fmt.Sprintf("https://kroki.io/%s/svg/%s", typeId, compressed)
+		if processor == model.BlockContentLatex_Chart {
+			if chart, ok := r.renderChart(b, text); ok {
+				return &EmbedRenderParams{Id: b.Id, Classes: classes, Content: chart}
 			}
 		}
 
 		// Process embedded content
-		if allowEmbedUrl(processor) && !regexp.MustCompile(`<iframe|script`).MatchString(text) {
-			text = getHtml(processor, getParsedUrl(text))
-		}
-
-		// Sketchfab embed handling
-		if processor == model.BlockContentLatex_Sketchfab && regexp.MustCompile(`<iframe|script`).MatchString(text) {
-			iframeMatch := regexp.MustCompile(`<iframe.*?</iframe>`).FindString(text)
-			if iframeMatch != "" {
-				text = iframeMatch
+		if options.AllowEmbedUrl && !regexp.MustCompile(`<iframe|script`).MatchString(text) {
+			embedUrl, ok := r.sanitizeEmbedUrl(r.getEmbedUrl(text))
+			if ok && options.ProviderUrlOnly {
+				ok = embedProcessor.MatchUrl(text)
+			}
+			if ok {
+				text = embedProcessor.Html(embedUrl)
+			} else {
+				text = html.EscapeString(text)
 			}
 		}
 
-		// Check if script tags should be allowed
-		if processor == model.BlockContentLatex_GithubGist {
-			allowScript = true
-		}
-
-		// Telegram embed handling
-		if processor == model.BlockContentLatex_Telegram {
-			allowScript = true
+		if !options.SkipSanitize && !options.AllowJs {
+			policy := options.Policy
+			if policy == nil {
+				policy = defaultEmbedPolicy
+			}
+			text, report = policy.Sanitize(text)
+			if !report.Empty() {
+				log.Warn("embed html sanitized",
+					zap.String("blockId", id),
+					zap.String("processor", style),
+					zap.String("stripped", report.String()))
+			}
 		}
 
-		if !allowScript {
-			text = regexp.MustCompile(`<script`).ReplaceAllString(text, "&lt;script")
-		}
+		facade = r.makeEmbedFacade(provider, latex.Text, text)
 
 		// Update sanitization parameters
-		if allowJs(processor) {
+		if options.AllowJs {
 			data.Js = text
 		} else {
 			data.Html = text
 		}
 
+	// source code is rendered on the page by js, which reads it as text
 	case model.BlockContentLatex_Latex:
-		break
+		text = html.EscapeString(text)
 
 	case model.BlockContentLatex_Mermaid:
-		text = fmt.Sprintf(`<div class="mermaidChart">%s</div>`, text)
+		text = fmt.Sprintf(`<div class="mermaidChart">%s</div>`, html.EscapeString(text))
 
 	case model.BlockContentLatex_Graphviz:
-		break
+		text = html.EscapeString(text)
 	}
 
 	return &EmbedRenderParams{
-		Id:       b.Id,
-		Classes:  classes,
-		Content:  text,
-		Data:     data,
-		IsIframe: isIframe,
-		Sandbox:  strings.Join(sandbox, " "),
+		Id:             b.Id,
+		Classes:        classes,
+		Content:        text,
+		Data:           data,
+		IsIframe:       isIframe,
+		Sandbox:        strings.Join(sandbox, " "),
+		Facade:         facade,
+		SanitizeReport: report,
 	}
 }
 
-func getProcessorByUrl(inputUrl string) *model.BlockContentLatexProcessor {
-	parsedUrl, err := url.Parse(inputUrl)
+// disabled embeds are rendered as a link to the embedded page
+func (r *Renderer) makeDisabledEmbedParams(b *model.Block, provider string, classes []string) *EmbedRenderParams {
+	link, _ := r.sanitizeUrl(embedSourceUrl(b.GetLatex().GetText()))
+	content, err := utils.TemplToString(EmbedLinkTemplate(embedProviderName(provider), templ.SafeURL(link)))
 	if err != nil {
-		return nil
+		log.Error("failed to render disabled embed link", zap.Error(err))
 	}
-
-	for processor, patterns := range domains {
-		for _, pattern := range patterns {
-			reg := regexp.MustCompile(`(?i)://([^.]*.)?` + pattern)
-
-			if reg.MatchString(inputUrl) {
-				// Restrict YouTube channel links
-				if processor == model.BlockContentLatex_Youtube && strings.HasPrefix(parsedUrl.Path, "/@") {
-					return nil
-				}
-				return &processor
-			}
-		}
+	return &EmbedRenderParams{
+		Id:      b.Id,
+		Classes: append(classes, "isDisabled"),
+		Content: content,
 	}
-	return nil
 }
 
-func getParsedUrl(inputUrl string) string {
-	processor := getProcessorByUrl(inputUrl)
-	if processor == nil {
+// getEmbedUrl converts page url into embed url of the provider it belongs to
+func (r *Renderer) getEmbedUrl(inputUrl string) string {
+	p := r.embedRegistry().MatchUrl(inputUrl)
+	if p == nil {
 		return inputUrl
 	}
-
-	switch *processor {
-	case model.BlockContentLatex_Youtube:
-		return fmt.Sprintf("https://www.youtube.com/embed/%s", extractYoutubeId(inputUrl))
-
-	case model.BlockContentLatex_Vimeo:
-		if parsed, err := url.Parse(inputUrl); err == nil {
-			return fmt.Sprintf("https://player.vimeo.com/video%s", parsed.Path)
-		}
-	case model.BlockContentLatex_GoogleMaps:
-		return parseGoogleMapsUrl(inputUrl)
-	case model.BlockContentLatex_Miro:
-		return strings.Split(inputUrl, "?")[0] + "/live-embed/"
-	case model.BlockContentLatex_Figma:
-		return fmt.Sprintf("https://www.figma.com/embed?embed_host=share&url=%s", url.QueryEscape(inputUrl))
-	case model.BlockContentLatex_OpenStreetMap:
-		return parseOpenStreetMapUrl(inputUrl)
-	case model.BlockContentLatex_Bilibili:
-		return parseBilibiliUrl(inputUrl)
-	case model.BlockContentLatex_Sketchfab:
-		return fmt.Sprintf("https://sketchfab.com/models/%s/embed", extractSketchfabId(inputUrl))
-	case model.BlockContentLatex_GithubGist:
-		return strings.Split(inputUrl, "#")[0]
-	}
-
-	return inputUrl
+	return p.EmbedUrl(inputUrl)
 }
 
 func extractYoutubeId(url string) string {
@@ -247,11 +461,29 @@ func extractYoutubeId(url string) string {
 	return id
 }
 
-func parseGoogleMapsUrl(inputUrl string) string {
-	if match := regexp.MustCompile(`place/([^/]+)`).FindStringSubmatch(inputUrl); match != nil {
-		return fmt.Sprintf("https://www.google.com/maps/embed/v1/place?key=%s&q=%s", GOOGLE_MAPS, url.QueryEscape(match[1]))
+// newGoogleMapsProcessor embeds places with Maps Embed API if apiKey is set,
+// and with keyless embed url otherwise
+func newGoogleMapsProcessor(apiKey string) *BasicEmbedProcessor {
+	return &BasicEmbedProcessor{
+		Id:      model.BlockContentLatex_GoogleMaps,
+		Domains: []string{`google\.(com?\.)?[a-z]{2,3}/maps`},
+		EmbedUrlFunc: func(inputUrl string) string {
+			return parseGoogleMapsUrl(inputUrl, apiKey)
+		},
+		HtmlFunc:     iframeHtml(`loading="lazy"`),
+		EmbedOptions: EmbedOptions{AllowEmbedUrl: true, Policy: iframePolicy("google.com")},
 	}
-	return inputUrl
+}
+
+func parseGoogleMapsUrl(inputUrl, apiKey string) string {
+	match := regexp.MustCompile(`place/([^/]+)`).FindStringSubmatch(inputUrl)
+	if match == nil {
+		return inputUrl
+	}
+	if apiKey == "" {
+		return fmt.Sprintf("https://www.google.com/maps?q=%s&output=embed", url.QueryEscape(match[1]))
+	}
+	return fmt.Sprintf("https://www.google.com/maps/embed/v1/place?key=%s&q=%s", url.QueryEscape(apiKey), url.QueryEscape(match[1]))
 }
 
 func parseOpenStreetMapUrl(inputUrl string) string {
@@ -289,139 +521,139 @@ func extractSketchfabId(inputUrl string) string {
 	return nameParts[len(nameParts)-1]
 }
 
-func getHtml(processor model.BlockContentLatexProcessor, content string) string {
-	fnName := fmt.Sprintf("Get%sHtml", processor.String())
-	method := reflect.ValueOf(&embedFunctions).MethodByName(fnName)
+type youtubeProcessor struct {
+	BasicEmbedProcessor
+}
 
-	if method.IsValid() {
-		results := method.Call([]reflect.Value{reflect.ValueOf(content)})
-		return results[0].String()
+// channel links can't be embedded
+func (p *youtubeProcessor) MatchUrl(inputUrl string) bool {
+	if parsedUrl, err := url.Parse(inputUrl); err != nil || strings.HasPrefix(parsedUrl.Path, "/@") {
+		return false
 	}
-	return content
+	return p.BasicEmbedProcessor.MatchUrl(inputUrl)
 }
 
-type embedFuncStruct struct{}
-
-var embedFunctions = embedFuncStruct{}
-
-func (e *embedFuncStruct) GetYoutubeHtml(content string) string {
-	parsedUrl, err := url.Parse(content)
+// stripUrlQuery drops query and fragment, which are tracking params and share ids for most providers
+func stripUrlQuery(inputUrl string) string {
+	parsedUrl, err := url.Parse(inputUrl)
 	if err != nil {
-		return content
+		return inputUrl
 	}
-	q := parsedUrl.Query()
-	q.Set("enablejsapi", "1")
-	q.Set("rel", "0")
-	parsedUrl.RawQuery = q.Encode()
-
-	return fmt.Sprintf(`<iframe id="player" src="%s" %s title="YouTube video player"></iframe>`, parsedUrl.String(), iframeParams)
-}
-
-func (e *embedFuncStruct) GetVimeoHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s allow="autoplay; fullscreen; picture-in-picture"></iframe>`, content, iframeParams)
-}
-
-func (e *embedFuncStruct) GetGoogleMapsHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s loading="lazy"></iframe>`, content, iframeParams)
-}
-
-func (e *embedFuncStruct) GetMiroHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s allow="fullscreen; clipboard-read; clipboard-write"></iframe>`, content, iframeParams)
-}
-
-func (e *embedFuncStruct) GetFigmaHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, content, iframeParams)
+	parsedUrl.RawQuery = ""
+	parsedUrl.Fragment = ""
+	return parsedUrl.String()
 }
 
-func (e *embedFuncStruct) GetOpenStreetMapHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, content, iframeParams)
+// x.com and mobile links are converted to canonical twitter.com post url
+func twitterEmbedUrl(inputUrl string) string {
+	parsedUrl, err := url.Parse(stripUrlQuery(inputUrl))
+	if err != nil {
+		return inputUrl
+	}
+	parsedUrl.Scheme = "https"
+	parsedUrl.Host = "twitter.com"
+	return parsedUrl.String()
 }
 
-func (e *embedFuncStruct) GetGithubGistHtml(content string) string {
-	return fmt.Sprintf(`<script src="%s.js"></script>`, content)
+func redditEmbedUrl(inputUrl string) string {
+	parsedUrl, err := url.Parse(stripUrlQuery(inputUrl))
+	if err != nil {
+		return inputUrl
+	}
+	parsedUrl.Scheme = "https"
+	parsedUrl.Host = "www.reddit.com"
+	return parsedUrl.String()
 }
 
-func (e *embedFuncStruct) GetCodepenHtml(content string) string {
-	parsedUrl, err := url.Parse(content)
+func instagramEmbedUrl(inputUrl string) string {
+	parsedUrl, err := url.Parse(stripUrlQuery(inputUrl))
 	if err != nil {
-		return ""
+		return inputUrl
 	}
-	parts := strings.Split(parsedUrl.Path, "/")
-	if len(parts) < 4 {
-		return ""
+	parsedUrl.Scheme = "https"
+	parsedUrl.Host = "www.instagram.com"
+	if !strings.HasSuffix(parsedUrl.Path, "/") {
+		parsedUrl.Path += "/"
 	}
-	return fmt.Sprintf(`<p class="codepen" data-height="300" data-default-tab="html,result" data-slug-hash="%s" data-user="%s"></p>`, parts[3], parts[1])
+	return parsedUrl.String()
 }
 
-func (e *embedFuncStruct) GetBilibiliHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, content, iframeParams)
+// widget markup is turned into a post by provider script, which embed iframe loads
+func twitterHtml(content string) string {
+	return fmt.Sprintf(`<blockquote class="twitter-tweet"><a href="%s"></a></blockquote>`, html.EscapeString(content))
 }
 
-func (e *embedFuncStruct) GetSketchfabHtml(content string) string {
-	return fmt.Sprintf(`<iframe src="%s" %s></iframe>`, content, iframeParams)
+func redditHtml(content string) string {
+	return fmt.Sprintf(`<blockquote class="reddit-embed-bq" data-embed-height="500"><a href="%s"></a></blockquote>`, html.EscapeString(content))
 }
 
-func (e *embedFuncStruct) GetImageHtml(content string) string {
-	return fmt.Sprintf(`<img src="%s" />`, content)
+func instagramHtml(content string) string {
+	return fmt.Sprintf(`<blockquote class="instagram-media" data-instgrm-permalink="%s" data-instgrm-version="14"></blockquote>`, html.EscapeString(content))
 }
 
-func allowPresentation(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Youtube: true, model.BlockContentLatex_Vimeo: true, model.BlockContentLatex_Bilibili: true,
+func facebookHtml(content string) string {
+	plugin := "post"
+	if strings.Contains(content, "/videos/") || strings.Contains(content, "/watch") || strings.Contains(content, "fb.watch") {
+		plugin = "video"
 	}
-	return allowed[p]
+	src := fmt.Sprintf("https://www.facebook.com/plugins/%s.php?href=%s&show_text=true", plugin, url.QueryEscape(content))
+	return fmt.Sprintf(`<iframe src="%s" %s allow="autoplay; clipboard-write; encrypted-media; picture-in-picture; web-share"></iframe>`, html.EscapeString(src), iframeParams)
 }
 
-func allowEmbedUrl(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Youtube: true, model.BlockContentLatex_Vimeo: true, model.BlockContentLatex_GoogleMaps: true,
-		model.BlockContentLatex_Miro: true, model.BlockContentLatex_Figma: true, model.BlockContentLatex_OpenStreetMap: true,
-		model.BlockContentLatex_Telegram: true, model.BlockContentLatex_GithubGist: true, model.BlockContentLatex_Codepen: true,
-		model.BlockContentLatex_Bilibili: true, model.BlockContentLatex_Kroki: true, model.BlockContentLatex_Sketchfab: true,
-		model.BlockContentLatex_Image: true,
+func youtubeHtml(content string) string {
+	parsedUrl, err := url.Parse(content)
+	if err != nil {
+		return content
 	}
-	return allowed[p]
-}
+	q := parsedUrl.Query()
+	q.Set("enablejsapi", "1")
+	q.Set("rel", "0")
+	parsedUrl.RawQuery = q.Encode()
 
-func allowJs(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Chart: true,
-	}
-	return allowed[p]
+	return fmt.Sprintf(`<iframe id="player" src="%s" %s title="YouTube video player"></iframe>`, html.EscapeString(parsedUrl.String()), iframeParams)
 }
 
-func allowPopup(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Bilibili: true,
+func vimeoEmbedUrl(inputUrl string) string {
+	if parsed, err := url.Parse(inputUrl); err == nil {
+		return fmt.Sprintf("https://player.vimeo.com/video%s", parsed.Path)
 	}
-	return allowed[p]
+	return inputUrl
 }
 
-func allowIframeResize(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Twitter: true, model.BlockContentLatex_Reddit: true, model.BlockContentLatex_Facebook: true,
-		model.BlockContentLatex_Instagram: true, model.BlockContentLatex_Telegram: true, model.BlockContentLatex_GithubGist: true,
-		model.BlockContentLatex_Codepen: true, model.BlockContentLatex_Kroki: true, model.BlockContentLatex_Chart: true,
-		model.BlockContentLatex_Image: true,
+func codepenHtml(content string) string {
+	parsedUrl, err := url.Parse(content)
+	if err != nil {
+		return ""
 	}
-	return allowed[p]
+	parts := strings.Split(parsedUrl.Path, "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	return fmt.Sprintf(`<p class="codepen" data-height="300" data-default-tab="html,result" data-slug-hash="%s" data-user="%s"></p>`, html.EscapeString(parts[3]), html.EscapeString(parts[1]))
 }
 
-func insertBeforeLoad(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Twitter: true, model.BlockContentLatex_Reddit: true, model.BlockContentLatex_Instagram: true,
-		model.BlockContentLatex_Codepen: true,
+// Fix Bilibili schemeless URLs and autoplay
+func prepareBilibili(_ *model.Block, text string) string {
+	reSrc := regexp.MustCompile(`src="(//player[^"]+)"`)
+	text = reSrc.ReplaceAllString(text, `src="https:$1"`)
+
+	reAutoplay := regexp.MustCompile(`autoplay=`)
+	if !reAutoplay.MatchString(text) {
+		reInsertAutoplay := regexp.MustCompile(`(src="[^"]+)`)
+		text = reInsertAutoplay.ReplaceAllString(text, `$1&autoplay=0`)
 	}
-	return allowed[p]
+	return text
 }
 
-func useRootHeight(p model.BlockContentLatexProcessor) bool {
-	allowed := map[model.BlockContentLatexProcessor]bool{
-		model.BlockContentLatex_Twitter: true, model.BlockContentLatex_Telegram: true, model.BlockContentLatex_Instagram: true,
-		model.BlockContentLatex_GithubGist: true, model.BlockContentLatex_Codepen: true, model.BlockContentLatex_Kroki: true,
-		model.BlockContentLatex_Chart: true,
+// Sketchfab embed code comes with attribution markup, only iframe is kept
+func prepareSketchfab(_ *model.Block, text string) string {
+	if !regexp.MustCompile(`<iframe|script`).MatchString(text) {
+		return text
+	}
+	if iframeMatch := regexp.MustCompile(`<iframe.*?</iframe>`).FindString(text); iframeMatch != "" {
+		return iframeMatch
 	}
-	return allowed[p]
+	return text
 }
 
 func compressAndEncode(text string) (string, error) {
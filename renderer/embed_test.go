@@ -0,0 +1,325 @@
+package renderer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeEmbedRenderParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor model.BlockContentLatexProcessor
+		text      string
+		expected  string
+	}{
+		{
+			name:      "youtube url",
+			processor: model.BlockContentLatex_Youtube,
+			text:      "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			expected:  `<iframe id="player" src="https://www.youtube.com/embed/dQw4w9WgXcQ?enablejsapi=1&amp;rel=0" frameborder="0" scrolling="no" allowfullscreen title="YouTube video player"></iframe>`,
+		},
+		{
+			name:      "javascript url",
+			processor: model.BlockContentLatex_Youtube,
+			text:      "javascript:alert(1)",
+			expected:  `javascript:alert(1)`,
+		},
+		{
+			name:      "data url",
+			processor: model.BlockContentLatex_Vimeo,
+			text:      "data:text/html,<b>test</b>",
+			expected:  `data:text/html,&lt;b&gt;test&lt;/b&gt;`,
+		},
+		{
+			name:      "image url with attribute injection",
+			processor: model.BlockContentLatex_Image,
+			text:      `https://example.com/a.png" onerror="alert(1)`,
+			expected:  `<img src="https://example.com/a.png&#34; onerror=&#34;alert(1)">`,
+		},
+		{
+			name:      "relative url",
+			processor: model.BlockContentLatex_Figma,
+			text:      "/embed/iframe.html",
+			expected:  `/embed/iframe.html`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			r := NewTestRenderer()
+			block := &model.Block{
+				Id: "embed",
+				Content: &model.BlockContentOfLatex{Latex: &model.BlockContentLatex{
+					Processor: tt.processor,
+					Text:      tt.text,
+				}},
+			}
+
+			// when
+			params := r.MakeEmbedRenderParams(block)
+
+			// then
+			assert.True(t, params.IsIframe)
+			assert.Equal(t, tt.expected, params.Data.Html)
+		})
+	}
+}
+
+func TestSocialEmbeds(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor model.BlockContentLatexProcessor
+		text      string
+		expected  string
+	}{
+		{
+			name:      "twitter post",
+			processor: model.BlockContentLatex_Twitter,
+			text:      "https://twitter.com/anytype/status/1234567890",
+			expected:  `<blockquote class="twitter-tweet"><a href="https://twitter.com/anytype/status/1234567890"></a></blockquote>`,
+		},
+		{
+			name:      "x post with share params",
+			processor: model.BlockContentLatex_Twitter,
+			text:      "https://x.com/anytype/status/1234567890?s=20&t=abc",
+			expected:  `<blockquote class="twitter-tweet"><a href="https://twitter.com/anytype/status/1234567890"></a></blockquote>`,
+		},
+		{
+			name:      "mobile twitter post",
+			processor: model.BlockContentLatex_Twitter,
+			text:      "https://mobile.twitter.com/anytype/status/1234567890",
+			expected:  `<blockquote class="twitter-tweet"><a href="https://twitter.com/anytype/status/1234567890"></a></blockquote>`,
+		},
+		{
+			name:      "reddit post",
+			processor: model.BlockContentLatex_Reddit,
+			text:      "https://www.reddit.com/r/golang/comments/abc123/some_title/",
+			expected:  `<blockquote class="reddit-embed-bq" data-embed-height="500"><a href="https://www.reddit.com/r/golang/comments/abc123/some_title/"></a></blockquote>`,
+		},
+		{
+			name:      "old reddit post with utm params",
+			processor: model.BlockContentLatex_Reddit,
+			text:      "https://old.reddit.com/r/golang/comments/abc123/some_title/?utm_source=share",
+			expected:  `<blockquote class="reddit-embed-bq" data-embed-height="500"><a href="https://www.reddit.com/r/golang/comments/abc123/some_title/"></a></blockquote>`,
+		},
+		{
+			name:      "instagram post",
+			processor: model.BlockContentLatex_Instagram,
+			text:      "https://www.instagram.com/p/CxYz123/?igshid=abc",
+			expected:  `<blockquote class="instagram-media" data-instgrm-permalink="https://www.instagram.com/p/CxYz123/" data-instgrm-version="14"></blockquote>`,
+		},
+		{
+			name:      "instagram reel without trailing slash",
+			processor: model.BlockContentLatex_Instagram,
+			text:      "https://instagram.com/reel/CxYz123",
+			expected:  `<blockquote class="instagram-media" data-instgrm-permalink="https://www.instagram.com/reel/CxYz123/" data-instgrm-version="14"></blockquote>`,
+		},
+		{
+			name:      "facebook post",
+			processor: model.BlockContentLatex_Facebook,
+			text:      "https://www.facebook.com/anytype/posts/pfbid0abc",
+			expected:  `<iframe src="https://www.facebook.com/plugins/post.php?href=https%3A%2F%2Fwww.facebook.com%2Fanytype%2Fposts%2Fpfbid0abc&amp;show_text=true" frameborder="0" scrolling="no" allowfullscreen allow="autoplay; clipboard-write; encrypted-media; picture-in-picture; web-share"></iframe>`,
+		},
+		{
+			name:      "facebook video",
+			processor: model.BlockContentLatex_Facebook,
+			text:      "https://www.facebook.com/anytype/videos/123/",
+			expected:  `<iframe src="https://www.facebook.com/plugins/video.php?href=https%3A%2F%2Fwww.facebook.com%2Fanytype%2Fvideos%2F123%2F&amp;show_text=true" frameborder="0" scrolling="no" allowfullscreen allow="autoplay; clipboard-write; encrypted-media; picture-in-picture; web-share"></iframe>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			r := NewTestRenderer()
+
+			// when
+			params := r.MakeEmbedRenderParams(embedBlock(tt.processor, tt.text))
+
+			// then
+			assert.Equal(t, tt.expected, params.Data.Html)
+			assert.True(t, params.SanitizeReport.Empty())
+		})
+	}
+}
+
+func TestSocialEmbedForeignHosts(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor model.BlockContentLatexProcessor
+		text      string
+	}{
+		{
+			name:      "subdomain of other domain ending with x",
+			processor: model.BlockContentLatex_Twitter,
+			text:      "https://x.company.io/anytype/status/1234567890",
+		},
+		{
+			name:      "provider domain is subdomain of other host",
+			processor: model.BlockContentLatex_Facebook,
+			text:      "https://facebook.com.evil.org/anytype/posts/pfbid0abc",
+		},
+		{
+			name:      "provider url in query",
+			processor: model.BlockContentLatex_Twitter,
+			text:      "https://evil.org/?u=https://x.com",
+		},
+		{
+			name:      "provider domain as userinfo",
+			processor: model.BlockContentLatex_Instagram,
+			text:      "https://instagram.com@evil.org/p/CxYz123/",
+		},
+		{
+			name:      "other embed block",
+			processor: model.BlockContentLatex_Youtube,
+			text:      "https://facebook.com.evil.org/anytype/posts/pfbid0abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			r := NewTestRenderer()
+
+			// when
+			params := r.MakeEmbedRenderParams(embedBlock(tt.processor, tt.text))
+
+			// then
+			assert.Nil(t, DefaultEmbedRegistry().MatchUrl(tt.text))
+			assert.NotContains(t, params.Data.Html, "twitter-tweet")
+			assert.NotContains(t, params.Data.Html, "instagram-media")
+			assert.NotContains(t, params.Data.Html, "facebook.com/plugins")
+			assert.NotContains(t, params.Data.Html, "<a ")
+		})
+	}
+}
+
+func embedBlock(processor model.BlockContentLatexProcessor, text string) *model.Block {
+	return &model.Block{
+		Id: "embed",
+		Content: &model.BlockContentOfLatex{Latex: &model.BlockContentLatex{
+			Processor: processor,
+			Text:      text,
+		}},
+	}
+}
+
+func TestEmbedFacade(t *testing.T) {
+	t.Run("embeds are loaded right away by default", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://www.youtube.com/watch?v=dQw4w9WgXcQ"))
+
+		// then
+		assert.Nil(t, params.Facade)
+	})
+	t.Run("url embed", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://www.youtube.com/watch?v=dQw4w9WgXcQ"))
+
+		// then
+		assert.Equal(t, &EmbedFacade{
+			Provider: "Youtube",
+			Name:     "YouTube",
+			Url:      "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		}, params.Facade)
+	})
+	t.Run("embed code", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Miro, `<iframe src="https://miro.com/app/live-embed/a=1&amp;b=2/" width="100"></iframe>`))
+
+		// then
+		assert.Equal(t, "Miro", params.Facade.Name)
+		assert.Equal(t, "https://miro.com/app/live-embed/a=1&b=2/", params.Facade.Url)
+	})
+	t.Run("chart is rendered without third parties", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, `{"type": "bar", "data": {"datasets": [{"data": [1, 2]}]}}`))
+
+		// then
+		assert.Nil(t, params.Facade)
+		assert.False(t, params.IsIframe)
+	})
+	t.Run("chart script loads library from cdn", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Chart, `{}`))
+
+		// then
+		require.NotNil(t, params.Facade)
+		assert.Equal(t, "jsDelivr", params.Facade.Name)
+	})
+	t.Run("thumbnail from bookmark in package", func(t *testing.T) {
+		// given
+		sourceUrl := "https://vimeo.com/123"
+		r := NewTestRenderer(
+			WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}, PublishFilesPath: "/files"}),
+			WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+				"objects/bookmark.pb": {
+					SbType: model.SmartBlockType_Page,
+					Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{Fields: map[string]*types.Value{
+							bundle.RelationKeySource.String():  pbtypes.String(sourceUrl),
+							bundle.RelationKeyPicture.String(): pbtypes.String("picture"),
+						}},
+					}},
+				},
+				"filesObjects/picture.pb": {
+					SbType: model.SmartBlockType_FileObject,
+					Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{Fields: map[string]*types.Value{
+							bundle.RelationKeySource.String(): pbtypes.String("files/picture.jpg"),
+						}},
+					}},
+				},
+			}),
+			WithPbFiles(map[string]string{"objects/bookmark.pb": "", "filesObjects/picture.pb": ""}),
+		)
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Vimeo, sourceUrl))
+
+		// then
+		assert.Equal(t, "/files/files/picture.jpg", params.Facade.Thumbnail)
+	})
+	t.Run("iframe waits for consent", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Consent: true}}))
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Vimeo, "https://vimeo.com/123"))
+
+		// when
+		builder := strings.Builder{}
+		err := IframeEmbedTemplate(r.Renderer, params).Render(context.Background(), &builder)
+
+		// then
+		assert.NoError(t, err)
+		out := builder.String()
+		assert.Contains(t, out, `<div class="embedFacade" data-provider="Vimeo">`)
+		assert.Contains(t, out, `<a class="url" href="https://vimeo.com/123"`)
+		assert.Contains(t, out, `data-src="/embed/iframe.html"`)
+		assert.NotContains(t, out, ` src="/embed/iframe.html"`)
+	})
+}
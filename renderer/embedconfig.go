@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// EmbedsConfig configures embed providers.
+// Providers are referred by processor name, e.g. "Youtube" or "GoogleMaps", case-insensitive.
+type EmbedsConfig struct {
+	// providers rendered as a link card instead of embed
+	Disabled []string `json:"disabled,omitempty"`
+	// provider api keys, only "GoogleMaps" uses one for now
+	ApiKeys map[string]string `json:"apiKeys,omitempty"`
+	// iframe hosts allowed in embed code besides the built-in ones
+	IframeHosts map[string][]string `json:"iframeHosts,omitempty"`
+	// iframe sandbox permissions which replace the default ones
+	Sandbox map[string][]string `json:"sandbox,omitempty"`
+
+	// render third-party embeds as click-to-load placeholders,
+	// nothing is requested from provider until reader agrees
+	Consent bool `json:"consent,omitempty"`
+	// kroki instance which renders diagrams, DefaultKrokiUrl if empty
+	KrokiUrl string `json:"krokiUrl,omitempty"`
+	// fetch kroki diagrams during rendering and inline them into the page
+	InlineKroki bool `json:"inlineKroki,omitempty"`
+
+	// custom embed processors, replace built-in ones with the same name
+	Processors []EmbedProcessor `json:"-"`
+}
+
+// providerValue finds value of provider in map with case-insensitive processor names as keys
+func providerValue[V any](values map[string]V, provider string) (V, bool) {
+	for name, value := range values {
+		if strings.EqualFold(name, provider) {
+			return value, true
+		}
+	}
+	var empty V
+	return empty, false
+}
+
+func (c *EmbedsConfig) IsDisabled(provider string) bool {
+	return slices.ContainsFunc(c.Disabled, func(name string) bool {
+		return strings.EqualFold(name, provider)
+	})
+}
+
+func (c *EmbedsConfig) ApiKey(provider string) string {
+	key, _ := providerValue(c.ApiKeys, provider)
+	return key
+}
+
+// embedOptions applies deployment overrides to processor options
+func (c *EmbedsConfig) embedOptions(p EmbedProcessor) EmbedOptions {
+	options := p.Options()
+	if hosts, ok := providerValue(c.IframeHosts, p.Name()); ok && len(hosts) != 0 {
+		base := options.Policy
+		if base == nil {
+			base = defaultEmbedPolicy
+		}
+		policy := *base
+		if !slices.Contains(policy.Tags, "iframe") {
+			policy.Tags = append(slices.Clone(policy.Tags), "iframe")
+			policy.Attributes = maps.Clone(policy.Attributes)
+			if policy.Attributes == nil {
+				policy.Attributes = make(map[string][]string)
+			}
+			policy.Attributes["iframe"] = iframeAttributes
+		}
+		policy.IframeHosts = append(slices.Clone(policy.IframeHosts), hosts...)
+		options.Policy = &policy
+	}
+	return options
+}
+
+// embedSandbox returns iframe sandbox permissions for processor
+func (c *EmbedsConfig) embedSandbox(provider string, options EmbedOptions) []string {
+	if sandbox, ok := providerValue(c.Sandbox, provider); ok {
+		return sandbox
+	}
+	sandbox := []string{"allow-scripts", "allow-same-origin", "allow-popups"}
+	for _, permission := range options.Sandbox {
+		if !slices.Contains(sandbox, permission) {
+			sandbox = append(sandbox, permission)
+		}
+	}
+	return sandbox
+}
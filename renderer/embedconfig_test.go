@@ -0,0 +1,131 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisabledEmbed(t *testing.T) {
+	t.Run("disabled provider is a link card", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Disabled: []string{"youtube"}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://www.youtube.com/watch?v=dQw4w9WgXcQ"))
+
+		// then
+		assert.False(t, params.IsIframe)
+		assert.Contains(t, params.Classes, "isDisabled")
+		assert.Equal(t, `<a class="embedLink" href="https://www.youtube.com/watch?v=dQw4w9WgXcQ" target="_blank" rel="noopener noreferrer nofollow"><div class="name">YouTube</div><div class="url">https://www.youtube.com/watch?v=dQw4w9WgXcQ</div></a>`, params.Content)
+	})
+	t.Run("disabled provider without url", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Disabled: []string{"Kroki"}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Kroki, "A -> B"))
+
+		// then
+		assert.False(t, params.IsIframe)
+		assert.Equal(t, `<div class="embedLink"><div class="name">Kroki</div></div>`, params.Content)
+	})
+	t.Run("other providers are embedded", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Disabled: []string{"Youtube"}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Vimeo, "https://vimeo.com/123"))
+
+		// then
+		assert.True(t, params.IsIframe)
+	})
+}
+
+func TestEmbedApiKeys(t *testing.T) {
+	placeUrl := "https://www.google.com/maps/place/Berlin"
+
+	t.Run("keyless google maps", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_GoogleMaps, placeUrl))
+
+		// then
+		assert.Contains(t, params.Data.Html, `src="https://www.google.com/maps?q=Berlin&amp;output=embed"`)
+	})
+	t.Run("google maps key", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{ApiKeys: map[string]string{"GoogleMaps": "secret"}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_GoogleMaps, placeUrl))
+
+		// then
+		assert.Contains(t, params.Data.Html, `src="https://www.google.com/maps/embed/v1/place?key=secret&amp;q=Berlin"`)
+	})
+}
+
+func TestEmbedIframeHosts(t *testing.T) {
+	code := `<iframe src="https://video.example.com/embed/1"></iframe>`
+
+	t.Run("unknown host is removed", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Vimeo, code))
+
+		// then
+		assert.Equal(t, "", params.Data.Html)
+	})
+	t.Run("allowed host", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{IframeHosts: map[string][]string{"Vimeo": {"example.com"}}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Vimeo, code))
+
+		// then
+		assert.Equal(t, code, params.Data.Html)
+		assert.True(t, params.SanitizeReport.Empty())
+	})
+	t.Run("iframe for provider without iframe policy", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{IframeHosts: map[string][]string{"Reddit": {"example.com"}}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Reddit, code))
+
+		// then
+		assert.Equal(t, code, params.Data.Html)
+		// shared policies stay untouched
+		assert.NotContains(t, r.embedRegistry().Get(model.BlockContentLatex_Reddit).Options().Policy.Tags, "iframe")
+		assert.NotContains(t, defaultEmbedPolicy.Tags, "iframe")
+	})
+}
+
+func TestEmbedSandbox(t *testing.T) {
+	t.Run("default sandbox", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://youtu.be/dQw4w9WgXcQ"))
+
+		// then
+		assert.Equal(t, "allow-scripts allow-same-origin allow-popups allow-presentation", params.Sandbox)
+	})
+	t.Run("sandbox override", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Sandbox: map[string][]string{"YOUTUBE": {"allow-scripts"}}}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://youtu.be/dQw4w9WgXcQ"))
+
+		// then
+		assert.Equal(t, "allow-scripts", params.Sandbox)
+	})
+}
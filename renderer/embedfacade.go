@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer/htmlsanitizer"
+)
+
+// EmbedFacade is a click-to-load placeholder, shown instead of third-party embed
+// until reader agrees to load it
+type EmbedFacade struct {
+	// key under which reader's choice is remembered
+	Provider  string
+	Name      string
+	Url       string
+	Thumbnail string
+}
+
+var embedProviderNames = map[string]string{
+	model.BlockContentLatex_Youtube.String():       "YouTube",
+	model.BlockContentLatex_GoogleMaps.String():    "Google Maps",
+	model.BlockContentLatex_OpenStreetMap.String(): "OpenStreetMap",
+	model.BlockContentLatex_GithubGist.String():    "GitHub Gist",
+	model.BlockContentLatex_Soundcloud.String():    "SoundCloud",
+	model.BlockContentLatex_Codepen.String():       "CodePen",
+	model.BlockContentLatex_Drawio.String():        "draw.io",
+	model.BlockContentLatex_Twitter.String():       "X",
+	model.BlockContentLatex_Chart.String():         "jsDelivr",
+}
+
+func embedProviderName(provider string) string {
+	if name, ok := embedProviderNames[provider]; ok {
+		return name
+	}
+	return provider
+}
+
+var embedSrcRegexp = regexp.MustCompile(`(?i)(?:src|href)\s*=\s*["']([^"']+)["']`)
+
+func embedWebUrl(candidate string) string {
+	if strings.ContainsAny(candidate, "<>\"' \n") {
+		return ""
+	}
+	cleanUrl, ok := htmlsanitizer.SanitizeUrl(candidate, htmlsanitizer.DefaultUrlSchemes)
+	if !ok || !strings.Contains(cleanUrl, "://") {
+		return ""
+	}
+	return cleanUrl
+}
+
+// embedSourceUrl finds the url embed points to: either the text itself,
+// or the first src/href of embed code
+func embedSourceUrl(texts ...string) string {
+	for _, text := range texts {
+		text = strings.TrimSpace(text)
+		if u := embedWebUrl(text); u != "" {
+			return u
+		}
+		if match := embedSrcRegexp.FindStringSubmatch(text); match != nil {
+			if u := embedWebUrl(html.UnescapeString(match[1])); u != "" {
+				return u
+			}
+		}
+	}
+	return ""
+}
+
+// embedThumbnail returns picture of a bookmark from the package with the same source
+func (r *Renderer) embedThumbnail(sourceUrl string) string {
+	if sourceUrl == "" {
+		return ""
+	}
+	if r.sourcePictures == nil {
+		r.sourcePictures = make(map[string]string)
+		for path := range r.UberSp.PbFiles {
+			if !strings.HasPrefix(path, "objects/") {
+				continue
+			}
+			snapshot, err := r.ReadJsonpbSnapshot(path)
+			if err != nil {
+				continue
+			}
+			details := snapshot.GetSnapshot().GetData().GetDetails()
+			source := getRelationField(details, bundle.RelationKeySource, relationToString)
+			picture := getRelationField(details, bundle.RelationKeyPicture, relationToString)
+			if source != "" && picture != "" {
+				r.sourcePictures[source] = picture
+			}
+		}
+	}
+
+	picture, ok := r.sourcePictures[sourceUrl]
+	if !ok {
+		return ""
+	}
+	src, err := r.getFileUrl(picture)
+	if err != nil {
+		return ""
+	}
+	return src
+}
+
+func (r *Renderer) makeEmbedFacade(provider string, texts ...string) *EmbedFacade {
+	// every iframe embed makes third-party requests, chart scripts too, they load chart.js from cdn
+	if !r.Config.Embeds.Consent {
+		return nil
+	}
+	sourceUrl := embedSourceUrl(texts...)
+	return &EmbedFacade{
+		Provider:  provider,
+		Name:      embedProviderName(provider),
+		Url:       sourceUrl,
+		Thumbnail: r.embedThumbnail(sourceUrl),
+	}
+}
@@ -0,0 +1,233 @@
+package renderer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer/htmlsanitizer"
+)
+
+// EmbedOptions defines how embed iframe is sandboxed and sized
+type EmbedOptions struct {
+	// sandbox permissions added to allow-scripts, allow-same-origin and allow-popups
+	Sandbox []string
+	// embed text may be a page url, which is converted into embed code
+	AllowEmbedUrl bool
+	// only urls of provider pages are embedded, other urls are shown as text
+	ProviderUrlOnly bool
+	// embed text is a script, which is run in the iframe without sanitizing
+	AllowJs bool
+	// embed text is a url which iframe fetches itself, so it is not sanitized as html
+	SkipSanitize bool
+	// iframe height follows its content
+	AllowIframeResize bool
+	InsertBeforeLoad  bool
+	UseRootHeight     bool
+	// which tags and attributes embed html may contain, text formatting only if nil
+	Policy *htmlsanitizer.Policy
+}
+
+// EmbedProcessor recognizes and renders embeds of one provider
+type EmbedProcessor interface {
+	// Name identifies provider in registry and EmbedsConfig,
+	// built-in processors are named after their block processor, e.g. "Youtube"
+	Name() string
+	// MatchUrl reports whether url is a page of the provider
+	MatchUrl(url string) bool
+	// EmbedUrl converts url of provider page into url of embeddable content
+	EmbedUrl(url string) string
+	// Html makes embed code from embed url
+	Html(embedUrl string) string
+	// Prepare fixes raw embed text before it is processed
+	Prepare(b *model.Block, text string) string
+	Options() EmbedOptions
+}
+
+// BasicEmbedProcessor is an EmbedProcessor made of plain values and funcs,
+// empty funcs leave embed text as is
+type BasicEmbedProcessor struct {
+	Id model.BlockContentLatexProcessor
+	// name of provider without block processor of its own, e.g. "Loom".
+	// Such providers render urls of their pages in embed blocks of any processor
+	Provider string
+	// regular expressions of provider hosts, which match subdomains too,
+	// optionally followed by a path, e.g. `example\.com/embed`. Host part can't contain "/"
+	Domains      []string
+	EmbedUrlFunc func(url string) string
+	HtmlFunc     func(embedUrl string) string
+	PrepareFunc  func(b *model.Block, text string) string
+	EmbedOptions EmbedOptions
+
+	compileOnce sync.Once
+	domainRes   []*regexp.Regexp
+	compileErr  error
+}
+
+func (p *BasicEmbedProcessor) Name() string {
+	if p.Provider != "" {
+		return p.Provider
+	}
+	return p.Id.String()
+}
+
+func (p *BasicEmbedProcessor) MatchUrl(url string) bool {
+	if p.compile() != nil {
+		return false
+	}
+	url = strings.TrimSpace(url)
+	for _, re := range p.domainRes {
+		if re.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile makes expressions of Domains once, they match only host of the url,
+// not e.g. a url in its query
+func (p *BasicEmbedProcessor) compile() error {
+	p.compileOnce.Do(func() {
+		for _, domain := range p.Domains {
+			host, path, hasPath := strings.Cut(domain, "/")
+			expr := `(?i)^[a-z][a-z0-9+.-]*://([^/?#@]*@)?([^/?#@]*\.)?(?:` + host + `)(:[0-9]+)?`
+			if hasPath {
+				expr += `/(?:` + path + `)`
+			} else {
+				expr += `([/?#]|$)`
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				p.compileErr = fmt.Errorf("invalid domain %q: %w", domain, err)
+				return
+			}
+			p.domainRes = append(p.domainRes, re)
+		}
+	})
+	return p.compileErr
+}
+
+func (p *BasicEmbedProcessor) EmbedUrl(url string) string {
+	if p.EmbedUrlFunc == nil {
+		return url
+	}
+	return p.EmbedUrlFunc(url)
+}
+
+func (p *BasicEmbedProcessor) Html(embedUrl string) string {
+	if p.HtmlFunc == nil {
+		return embedUrl
+	}
+	return p.HtmlFunc(embedUrl)
+}
+
+func (p *BasicEmbedProcessor) Prepare(b *model.Block, text string) string {
+	if p.PrepareFunc == nil {
+		return text
+	}
+	return p.PrepareFunc(b, text)
+}
+
+func (p *BasicEmbedProcessor) Options() EmbedOptions {
+	return p.EmbedOptions
+}
+
+// EmbedRegistry holds embed processors by name.
+// Urls are matched against processors in registration order, latest first.
+type EmbedRegistry struct {
+	processors map[string]EmbedProcessor
+	order      []string
+}
+
+func NewEmbedRegistry(processors ...EmbedProcessor) (*EmbedRegistry, error) {
+	reg := &EmbedRegistry{processors: make(map[string]EmbedProcessor)}
+	for _, p := range processors {
+		if err := reg.Register(p); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// DefaultEmbedRegistry returns a registry with built-in processors
+func DefaultEmbedRegistry() *EmbedRegistry {
+	reg, err := NewEmbedRegistry(builtinEmbedProcessors()...)
+	if err != nil {
+		// domains of built-in processors are constant
+		panic(err)
+	}
+	return reg
+}
+
+// Register adds processor, replacing one registered with the same name.
+// Domains of BasicEmbedProcessor are compiled here, invalid ones are reported.
+func (reg *EmbedRegistry) Register(p EmbedProcessor) error {
+	if c, ok := p.(interface{ compile() error }); ok {
+		if err := c.compile(); err != nil {
+			return fmt.Errorf("embed processor %s: %w", p.Name(), err)
+		}
+	}
+	name := p.Name()
+	reg.order = slices.DeleteFunc(reg.order, func(registered string) bool {
+		return registered == name
+	})
+	reg.order = append(reg.order, name)
+	reg.processors[name] = p
+	return nil
+}
+
+// Get returns processor for block processor, a processor without url support if none is registered
+func (reg *EmbedRegistry) Get(id model.BlockContentLatexProcessor) EmbedProcessor {
+	if p, ok := reg.processors[id.String()]; ok {
+		return p
+	}
+	return &BasicEmbedProcessor{Id: id}
+}
+
+// ForBlock returns provider without block processor of its own, if embed text is a url of its page,
+// and processor of the block otherwise
+func (reg *EmbedRegistry) ForBlock(id model.BlockContentLatexProcessor, text string) EmbedProcessor {
+	if p := reg.MatchUrl(strings.TrimSpace(text)); p != nil && !isBlockProcessorName(p.Name()) {
+		return p
+	}
+	return reg.Get(id)
+}
+
+func isBlockProcessorName(name string) bool {
+	_, ok := model.BlockContentLatexProcessor_value[name]
+	return ok
+}
+
+// MatchUrl finds processor of provider which url belongs to, nil if there is none
+func (reg *EmbedRegistry) MatchUrl(url string) EmbedProcessor {
+	for i := len(reg.order) - 1; i >= 0; i-- {
+		if p := reg.processors[reg.order[i]]; p.MatchUrl(url) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (r *Renderer) embedRegistry() *EmbedRegistry {
+	if r.embedProcessors == nil {
+		r.embedProcessors = DefaultEmbedRegistry()
+		processors := slices.Clone(r.Config.Embeds.Processors)
+		if key := r.Config.Embeds.ApiKey(model.BlockContentLatex_GoogleMaps.String()); key != "" {
+			processors = append([]EmbedProcessor{newGoogleMapsProcessor(key)}, processors...)
+		}
+		if r.Config.Embeds.KrokiUrl != "" {
+			processors = append([]EmbedProcessor{newKrokiProcessor(r.Config.Embeds.KrokiUrl)}, processors...)
+		}
+		for _, p := range processors {
+			if err := r.embedProcessors.Register(p); err != nil {
+				log.Error("failed to register embed processor", zap.Error(err))
+			}
+		}
+	}
+	return r.embedProcessors
+}
@@ -0,0 +1,181 @@
+package renderer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedRegistryMatchUrl(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected model.BlockContentLatexProcessor
+		found    bool
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", model.BlockContentLatex_Youtube, true},
+		{"https://youtu.be/dQw4w9WgXcQ", model.BlockContentLatex_Youtube, true},
+		{"https://www.youtube.com/@channel", 0, false},
+		{"https://vimeo.com/123", model.BlockContentLatex_Vimeo, true},
+		{"https://www.google.com/maps/place/Berlin", model.BlockContentLatex_GoogleMaps, true},
+		{"https://gist.github.com/user/abc", model.BlockContentLatex_GithubGist, true},
+		{"https://x.com/anytype/status/1", model.BlockContentLatex_Twitter, true},
+		{"https://box.com/x.com", 0, false},
+		{"https://old.reddit.com/r/golang", model.BlockContentLatex_Reddit, true},
+		{"https://www.instagram.com/p/abc/", model.BlockContentLatex_Instagram, true},
+		{"https://m.facebook.com/story.php", model.BlockContentLatex_Facebook, true},
+		{"https://example.com/video", 0, false},
+	}
+
+	reg := DefaultEmbedRegistry()
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			// when
+			p := reg.MatchUrl(tt.url)
+
+			// then
+			if !tt.found {
+				assert.Nil(t, p)
+				return
+			}
+			assert.Equal(t, tt.expected.String(), p.Name())
+		})
+	}
+}
+
+func TestEmbedRegistryGet(t *testing.T) {
+	t.Run("processor without registration", func(t *testing.T) {
+		// given
+		reg, err := NewEmbedRegistry()
+		require.NoError(t, err)
+
+		// when
+		p := reg.Get(model.BlockContentLatex_Spotify)
+
+		// then
+		assert.Equal(t, "Spotify", p.Name())
+		assert.Equal(t, EmbedOptions{}, p.Options())
+		assert.Equal(t, "text", p.Html("text"))
+	})
+	t.Run("register replaces processor", func(t *testing.T) {
+		// given
+		reg := DefaultEmbedRegistry()
+		custom := &BasicEmbedProcessor{Id: model.BlockContentLatex_Youtube}
+
+		// when
+		err := reg.Register(custom)
+
+		// then
+		require.NoError(t, err)
+		assert.Same(t, custom, reg.Get(model.BlockContentLatex_Youtube))
+		assert.Nil(t, reg.MatchUrl("https://www.youtube.com/watch?v=dQw4w9WgXcQ"))
+	})
+	t.Run("invalid domain is reported", func(t *testing.T) {
+		// given
+		reg := DefaultEmbedRegistry()
+
+		// when
+		err := reg.Register(&BasicEmbedProcessor{Provider: "Broken", Domains: []string{`broken\.com(`}})
+
+		// then
+		assert.ErrorContains(t, err, "Broken")
+		assert.NotNil(t, reg.MatchUrl("https://vimeo.com/123"))
+	})
+}
+
+func TestBasicEmbedProcessorMatchUrl(t *testing.T) {
+	tests := []struct {
+		domain   string
+		url      string
+		expected bool
+	}{
+		{`example\.com`, "https://example.com", true},
+		{`example\.com`, "https://www.example.com/video?id=1", true},
+		{`example\.com`, "https://example.com:8443/video", true},
+		{`example\.com`, "  https://example.com/video\n", true},
+		{`example\.com`, "https://example.company.io/video", false},
+		{`example\.com`, "https://example.com.evil.org/video", false},
+		{`example\.com`, "https://notexample.com/video", false},
+		{`example\.com`, "https://evil.org/?u=https://example.com", false},
+		{`example\.com`, "https://evil.org/#https://example.com", false},
+		{`example\.com`, "https://example.com@evil.org/video", false},
+		{`example\.com`, "example.com/video", false},
+		{`openstreetmap\.org/\#map`, "https://www.openstreetmap.org/#map=15/52.5/13.4", true},
+		{`openstreetmap\.org/\#map`, "https://www.openstreetmap.org/user/abc", false},
+		{`google\.(com?\.)?[a-z]{2,3}/maps`, "https://www.google.com/maps/place/Berlin", true},
+		{`google\.(com?\.)?[a-z]{2,3}/maps`, "https://www.google.co.uk/maps/place/London", true},
+		{`google\.(com?\.)?[a-z]{2,3}/maps`, "https://google.evil.org/maps/place/Berlin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			// given
+			p := &BasicEmbedProcessor{Domains: []string{tt.domain}}
+
+			// when
+			actual := p.MatchUrl(tt.url)
+
+			// then
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestInvalidCustomEmbedProcessor(t *testing.T) {
+	// given
+	broken := &BasicEmbedProcessor{Provider: "Broken", Domains: []string{`(`}}
+	r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{Processors: []EmbedProcessor{broken}}}))
+
+	// when
+	params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Youtube, "https://www.youtube.com/watch?v=dQw4w9WgXcQ"))
+
+	// then
+	assert.False(t, broken.MatchUrl("https://example.com"))
+	assert.Contains(t, params.Data.Html, "https://www.youtube.com/embed/dQw4w9WgXcQ")
+}
+
+func TestCustomEmbedProcessor(t *testing.T) {
+	// given
+	loom := &BasicEmbedProcessor{
+		Provider: "Loom",
+		Domains:  []string{`loom\.com`},
+		EmbedUrlFunc: func(url string) string {
+			return fmt.Sprintf("https://www.loom.com/embed/%s", url[len("https://www.loom.com/share/"):])
+		},
+		HtmlFunc: func(embedUrl string) string {
+			return fmt.Sprintf(`<iframe src="%s"></iframe>`, embedUrl)
+		},
+		EmbedOptions: EmbedOptions{
+			Sandbox:           []string{"allow-presentation", "allow-scripts"},
+			AllowEmbedUrl:     true,
+			AllowIframeResize: true,
+			Policy:            iframePolicy("loom.com"),
+		},
+	}
+	r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{
+		Processors: []EmbedProcessor{loom},
+		Sandbox:    map[string][]string{"loom": {"allow-scripts"}},
+	}}))
+
+	t.Run("provider url in any embed block", func(t *testing.T) {
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Spotify, "https://www.loom.com/share/abc"))
+
+		// then
+		assert.Equal(t, `<iframe src="https://www.loom.com/embed/abc"></iframe>`, params.Data.Html)
+		assert.Equal(t, "allow-scripts", params.Sandbox)
+		assert.True(t, params.Data.AllowIframeResize)
+	})
+	t.Run("built-in processor is kept", func(t *testing.T) {
+		// when
+		params := r.MakeEmbedRenderParams(embedBlock(model.BlockContentLatex_Spotify, `<iframe src="https://open.spotify.com/embed/track/abc"></iframe>`))
+
+		// then
+		assert.NotSame(t, loom, r.embedRegistry().Get(model.BlockContentLatex_Spotify))
+		assert.Equal(t, model.BlockContentLatex_Spotify, params.Data.Processor)
+		assert.Contains(t, params.Data.Html, `src="https://open.spotify.com/embed/track/abc"`)
+		assert.Equal(t, "allow-scripts allow-same-origin allow-popups", params.Sandbox)
+	})
+}
@@ -0,0 +1,116 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gogo/protobuf/types"
+)
+
+// EmojiMode defines how emojies are rendered
+type EmojiMode int
+
+const (
+	// png images from RenderConfig.AnytypeCdnUrl
+	EmojiModeCdn EmojiMode = iota
+	// unicode text, rendered by the system font
+	EmojiModeNative
+	// png images from RenderConfig.EmojiAssetsPath, named the same way as on cdn
+	EmojiModeLocal
+)
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	variationSelector = '\ufe0f'
+	keycapCombining   = '\u20e3'
+)
+
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// tags are used in subdivision flags, e.g. England
+func isEmojiTag(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007f
+}
+
+// firstEmoji returns the first emoji grapheme cluster of s,
+// including skin tones, ZWJ sequences, flags and keycaps
+func firstEmoji(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if first == utf8.RuneError {
+		return ""
+	}
+
+	end := size
+	if isRegionalIndicator(first) {
+		if next, nextSize := utf8.DecodeRuneInString(s[end:]); isRegionalIndicator(next) {
+			end += nextSize
+		}
+		return s[:end]
+	}
+
+	for end < len(s) {
+		next, nextSize := utf8.DecodeRuneInString(s[end:])
+		switch {
+		case next == variationSelector, next == keycapCombining, isEmojiModifier(next), isEmojiTag(next):
+			end += nextSize
+		case next == zeroWidthJoiner:
+			end += nextSize
+			if end < len(s) {
+				_, joinedSize := utf8.DecodeRuneInString(s[end:])
+				end += joinedSize
+			}
+		default:
+			return s[:end]
+		}
+	}
+	return s[:end]
+}
+
+// emojiCode is an image name of emoji: its codepoints in hex joined with "-".
+// Variation selectors are dropped, they don't change the image.
+func emojiCode(emoji string) string {
+	codes := make([]string, 0, utf8.RuneCountInString(emoji))
+	for _, r := range emoji {
+		if r == variationSelector {
+			continue
+		}
+		codes = append(codes, fmt.Sprintf("%x", r))
+	}
+	return strings.Join(codes, "-")
+}
+
+func relationToEmoji(emojiField *types.Value) string {
+	return firstEmoji(emojiField.GetStringValue())
+}
+
+// emojiMode returns mode emojies are rendered in
+func (r *Renderer) emojiMode() EmojiMode {
+	// cdn images would be external requests, system font is used instead
+	if r.Config.EmojiMode == EmojiModeCdn && r.isSingleFile() {
+		return EmojiModeNative
+	}
+	return r.Config.EmojiMode
+}
+
+// GetEmojiUrl returns image url of emoji, or empty string in EmojiModeNative
+// and for cdn emojies of single file pages
+func (r *Renderer) GetEmojiUrl(emoji string) string {
+	if emoji == "" {
+		return ""
+	}
+	switch r.emojiMode() {
+	case EmojiModeNative:
+		return ""
+	case EmojiModeLocal:
+		return r.assetUrl(AssetOriginEmoji, emojiCode(emoji)+".png")
+	default:
+		return r.assetUrl(AssetOriginAnytypeCdn, "emojies/"+emojiCode(emoji)+".png")
+	}
+}
@@ -0,0 +1,55 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstEmoji(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"single", "😃", "😃"},
+		{"trailing text", "😃abc", "😃"},
+		{"variation selector", "❤️", "❤️"},
+		{"skin tone", "👍🏾👍", "👍🏾"},
+		{"zwj sequence", "👩‍💻", "👩‍💻"},
+		{"zwj with skin tone", "👩🏽‍💻", "👩🏽‍💻"},
+		{"family", "👨‍👩‍👧‍👦", "👨‍👩‍👧‍👦"},
+		{"flag", "🇺🇦🇺🇸", "🇺🇦"},
+		{"keycap", "1️⃣", "1️⃣"},
+		{"subdivision flag", "🏴󠁧󠁢󠁥󠁮󠁧󠁿", "🏴󠁧󠁢󠁥󠁮󠁧󠁿"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, firstEmoji(tt.input))
+		})
+	}
+}
+
+func TestGetEmojiUrl(t *testing.T) {
+	t.Run("cdn", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{AnytypeCdnUrl: "https://cdn"}))
+
+		assert.Equal(t, "https://cdn/emojies/2764.png", r.GetEmojiUrl("❤️"))
+	})
+	t.Run("local", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{EmojiMode: EmojiModeLocal, EmojiAssetsPath: "/static/emoji"}))
+
+		assert.Equal(t, "/static/emoji/1f1fa-1f1e6.png", r.GetEmojiUrl("🇺🇦"))
+	})
+	t.Run("native", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{EmojiMode: EmojiModeNative}))
+
+		assert.Empty(t, r.GetEmojiUrl("😃"))
+	})
+	t.Run("cdn in single file", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{AnytypeCdnUrl: "https://cdn", SingleFile: &SingleFileConfig{}}))
+
+		assert.Empty(t, r.GetEmojiUrl("😃"))
+	})
+}
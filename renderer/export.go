@@ -0,0 +1,222 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/a-h/templ"
+	"go.uber.org/zap"
+)
+
+const (
+	exportPage      = "index.html"
+	exportManifest  = "manifest.json"
+	exportAssetsDir = "assets"
+)
+
+var (
+	assetExtRe = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+	// extensions of content types which http.DetectContentType recognizes in media files
+	sniffedExts = map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"image/gif":       ".gif",
+		"image/webp":      ".webp",
+		"image/bmp":       ".bmp",
+		"application/pdf": ".pdf",
+		"video/mp4":       ".mp4",
+		"video/webm":      ".webm",
+		"video/avi":       ".avi",
+		"audio/mpeg":      ".mp3",
+		"audio/wave":      ".wav",
+		"audio/ogg":       ".ogg",
+		"text/plain":      ".txt",
+	}
+)
+
+// ExportConfig describes export of a page into a directory, which can be pushed to any static host.
+// Static build and package files are copied, so StaticFilesPath and PublishFilesPath
+// must be local directories.
+type ExportConfig struct {
+	// directory page, assets and manifest are written to
+	Dir string
+	// url of assets directory on the published page, relative "assets" if empty
+	AssetsUrl string
+}
+
+// ExportAsset is a file copied into export directory
+type ExportAsset struct {
+	// url of the file in the page before export
+	Source string `json:"source"`
+	// path relative to export directory
+	Path string `json:"path"`
+	Url  string `json:"url"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+	// sha256 of the content in hex, file name is its prefix
+	Hash string `json:"hash"`
+}
+
+// ExportManifest lists files of export directory, e.g. to upload them with their content types.
+// Assets are named by content hash, so they can be cached forever.
+type ExportManifest struct {
+	Page   string        `json:"page"`
+	Assets []ExportAsset `json:"assets"`
+}
+
+func (r *Renderer) isExport() bool {
+	return r.Config.Export != nil
+}
+
+// RenderBuildStyles renders links to css of static build, which is copied with export
+func (r *Renderer) RenderBuildStyles() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		css, _, err := r.staticBuildFiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range css {
+			if _, err := fmt.Fprintf(w, `<link rel="stylesheet" type="text/css" href="%s" />`, templ.EscapeString(r.GetStaticFolderUrl("/js/build/"+name))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RenderBuildScripts renders scripts of static build, which is copied with export
+func (r *Renderer) RenderBuildScripts() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		_, js, err := r.staticBuildFiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range js {
+			if _, err := fmt.Fprintf(w, `<script src="%s" type="text/javascript" defer></script>`, templ.EscapeString(r.GetStaticFolderUrl("/js/build/"+name))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Export writes page into Config.Export.Dir together with the files it references
+// and manifest.json. Files are renamed by content hash and urls in the page are rewritten to them.
+func (r *Renderer) Export() (manifest *ExportManifest, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			stack := string(debug.Stack())
+			err = fmt.Errorf("panic: %v, publishFilesPath: %s, stack: %s", p, r.Config.PublishFilesPath, stack)
+			log.Error("panic recover", zap.String("where", "Export()"), zap.Error(err), zap.String("stack", stack))
+		}
+	}()
+	if !r.isExport() {
+		return nil, errors.New("export is not configured")
+	}
+
+	if err = os.MkdirAll(r.Config.Export.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err = r.RootComp.Render(context.Background(), &buffer); err != nil {
+		return nil, err
+	}
+	manifest = &ExportManifest{Page: exportPage}
+	rewriter := r.newAssetRewriter(true, func(local localAsset) (string, error) {
+		asset, err := r.exportAsset(local)
+		if err != nil {
+			return "", err
+		}
+		// the same content may be referenced by different urls
+		if !slices.ContainsFunc(manifest.Assets, func(a ExportAsset) bool { return a.Path == asset.Path }) {
+			manifest.Assets = append(manifest.Assets, asset)
+		}
+		// nested pages are in assets directory themselves
+		if local.Nested && !isAbsoluteUrl(asset.Url) {
+			return path.Base(asset.Path), nil
+		}
+		return asset.Url, nil
+	})
+	page, err := rewriter.rewriteHtml(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewrite asset urls: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(r.Config.Export.Dir, exportPage), page, 0o644); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(manifest.Assets, func(a, b ExportAsset) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(r.Config.Export.Dir, exportManifest), data, 0o644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func isAbsoluteUrl(rawUrl string) bool {
+	return strings.HasPrefix(rawUrl, "/") || strings.Contains(rawUrl, "://")
+}
+
+// exportAsset copies file into assets directory under its content hash
+func (r *Renderer) exportAsset(local localAsset) (ExportAsset, error) {
+	data := local.Data
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	mimeType := assetMimeType(local.Path, data)
+	name := hash[:32] + assetExt(local.Path, mimeType)
+
+	dir := filepath.Join(r.Config.Export.Dir, exportAssetsDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ExportAsset{}, err
+	}
+	target := filepath.Join(dir, name)
+	// same name means same content, it was copied already
+	if _, err := os.Stat(target); err != nil {
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return ExportAsset{}, err
+		}
+	}
+
+	assetsUrl := strings.TrimSuffix(r.Config.Export.AssetsUrl, "/")
+	if assetsUrl == "" {
+		assetsUrl = exportAssetsDir
+	}
+	return ExportAsset{
+		Source: local.Url,
+		Path:   exportAssetsDir + "/" + name,
+		Url:    assetsUrl + "/" + name,
+		Type:   mimeType,
+		Size:   int64(len(data)),
+		Hash:   hash,
+	}, nil
+}
+
+// assetExt keeps extension of the file, static hosts choose content type by it.
+// Package files may have no extension, then it is taken from the content type.
+func assetExt(path, mimeType string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if assetExtRe.MatchString(ext) {
+		return ext
+	}
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	return sniffedExts[mediaType]
+}
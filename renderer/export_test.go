@@ -0,0 +1,148 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	static, files, out := filepath.Join(dir, "static"), filepath.Join(dir, "package"), filepath.Join(dir, "out")
+	writeTestStaticBuild(t, static)
+	writeTestFile(t, filepath.Join(static, "img", "og-image.png"), "og")
+	writeTestFile(t, filepath.Join(files, "files", "photo.jpg"), "photo")
+	writeTestFile(t, filepath.Join(files, "files", "copy.jpg"), "photo")
+	writeTestFile(t, filepath.Join(files, "files", "report"), "%PDF-1.4")
+	r := NewTestRenderer(WithConfig(RenderConfig{
+		StaticFilesPath:  static,
+		PublishFilesPath: files,
+		Export:           &ExportConfig{Dir: out},
+	}))
+	r.RootComp = templ.Join(
+		templ.Raw(`<meta property="og:image" content="`+static+`/img/og-image.png">`),
+		r.RenderBuildStyles(),
+		templ.Raw(`<img src="`+files+`/files/photo.jpg"><img src="`+files+`/files/copy.jpg">`),
+		templ.Raw(`<a href="`+files+`/files/report">Report</a><a href="https://example.com">Site</a><a href="#heading">Heading</a>`),
+		r.RenderBuildScripts(),
+	)
+	hash := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	// when
+	manifest, err := r.Export()
+
+	// then
+	require.NoError(t, err)
+	page, err := os.ReadFile(filepath.Join(out, "index.html"))
+	require.NoError(t, err)
+	photo := "assets/" + hash("photo")[:32] + ".jpg"
+	assert.Equal(t, `<meta property="og:image" content="assets/`+hash("og")[:32]+`.png">`+
+		`<link rel="stylesheet" type="text/css" href="assets/`+hash("body { opacity: 1; }\n/*# sourceMappingURL=main.3c4d.css.map*/")[:32]+`.css"/>`+
+		`<img src="`+photo+`"><img src="`+photo+`">`+
+		`<a href="assets/`+hash("%PDF-1.4")[:32]+`.pdf">Report</a><a href="https://example.com">Site</a><a href="#heading">Heading</a>`+
+		`<script src="assets/`+hash("console.log('</script>');\n//# sourceMappingURL=main.1a2b.js.map")[:32]+`.js" type="text/javascript" defer="">`+`</script>`, string(page))
+
+	assert.Len(t, manifest.Assets, 5)
+	assert.Contains(t, manifest.Assets, ExportAsset{
+		Source: files + "/files/photo.jpg",
+		Path:   photo,
+		Url:    photo,
+		Type:   "image/jpeg",
+		Size:   5,
+		Hash:   hash("photo"),
+	})
+	for _, asset := range manifest.Assets {
+		assert.FileExists(t, filepath.Join(out, asset.Path))
+	}
+	data, err := os.ReadFile(filepath.Join(out, "manifest.json"))
+	require.NoError(t, err)
+	var written ExportManifest
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, *manifest, written)
+}
+
+func TestExportAssetsUrl(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "files", "photo.png"), "photo")
+	r := NewTestRenderer(WithConfig(RenderConfig{
+		PublishFilesPath: dir,
+		Export:           &ExportConfig{Dir: filepath.Join(dir, "out"), AssetsUrl: "https://cdn.example.com/page/"},
+	}))
+	r.RootComp = templ.Raw(`<div class="cover" style="background-image: url(` + dir + `/files/photo.png)"></div>`)
+
+	// when
+	manifest, err := r.Export()
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, manifest.Assets, 1)
+	assert.Equal(t, "https://cdn.example.com/page/"+manifest.Assets[0].Hash[:32]+".png", manifest.Assets[0].Url)
+	page, err := os.ReadFile(filepath.Join(dir, "out", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `url(&#34;`+manifest.Assets[0].Url+`&#34;)`)
+}
+
+func TestAssetExt(t *testing.T) {
+	assert.Equal(t, ".jpg", assetExt("files/photo.JPG", "image/jpeg"))
+	assert.Equal(t, ".png", assetExt("files/anytype_downloaded_file_1", "image/png"))
+	assert.Equal(t, ".txt", assetExt("files/notes", "text/plain;charset=utf-8"))
+	assert.Equal(t, "", assetExt("files/data", "application/octet-stream"))
+}
+
+func TestExportNestedPage(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	static := filepath.Join(dir, "static")
+	writeTestFile(t, filepath.Join(static, "js", "jquery.js"), "jquery")
+	writeTestFile(t, filepath.Join(static, "embed", "iframe.html"), `<script src="../js/jquery.js"></script>`)
+	page := templ.Raw(`<iframe src="` + static + `/embed/iframe.html"></iframe>`)
+	export := func(assetsUrl string) (*ExportManifest, string) {
+		out := filepath.Join(dir, "out"+assetsUrl[:1])
+		r := NewTestRenderer(WithConfig(RenderConfig{
+			StaticFilesPath: static,
+			Export:          &ExportConfig{Dir: out, AssetsUrl: assetsUrl},
+		}))
+		r.RootComp = page
+		manifest, err := r.Export()
+		require.NoError(t, err)
+		require.Len(t, manifest.Assets, 2)
+		for _, asset := range manifest.Assets {
+			if asset.Type == "text/html;charset=utf-8" {
+				iframe, err := os.ReadFile(filepath.Join(out, asset.Path))
+				require.NoError(t, err)
+				return manifest, string(iframe)
+			}
+		}
+		require.Fail(t, "iframe page is not exported")
+		return nil, ""
+	}
+	sum := sha256.Sum256([]byte("jquery"))
+	jquery := hex.EncodeToString(sum[:])[:32] + ".js"
+
+	t.Run("relative assets url", func(t *testing.T) {
+		// when
+		_, iframe := export("assets")
+
+		// then
+		assert.Equal(t, `<script src="`+jquery+`"></script>`, iframe)
+	})
+	t.Run("absolute assets url", func(t *testing.T) {
+		// when
+		_, iframe := export("https://cdn.example.com/assets")
+
+		// then
+		assert.Equal(t, `<script src="https://cdn.example.com/assets/`+jquery+`"></script>`, iframe)
+	})
+}
@@ -16,10 +16,13 @@ import (
 )
 
 type FileRenderParams struct {
-	Id   string
-	Src  templ.SafeURL
-	Name string
-	Size string
+	Id          string
+	Src         templ.SafeURL
+	Name        string
+	Description string
+	Size        string
+	// path of the file in the package
+	Source string
 }
 
 func (params *FileRenderParams) ToFileMediaRenderParams(width string, classes []string) *FileMediaRenderParams {
@@ -40,6 +43,23 @@ type FileMediaRenderParams struct {
 	Width   string
 	Name    string
 	Size    string
+	// intrinsic image dimensions, 0 if unknown
+	ImageWidth  int
+	ImageHeight int
+	Srcset      string
+	Sizes       string
+	// alternative text for screen readers
+	Alt string
+	// visible caption under media, none if empty
+	Caption string
+	Tracks  []MediaTrack
+	// image opens in lightbox if set
+	Gallery *GalleryItem
+	// video thumbnail
+	Poster string
+	Audio  *AudioMeta
+	// pdf page count, 0 if unknown
+	Pages int
 }
 
 type SizeSpanRenderParams struct {
@@ -72,7 +92,7 @@ func (r *Renderer) getFileUrl(id string) (url string, err error) {
 
 	// fixes GO-4975
 	source = strings.ReplaceAll(source, `\`, "%5C")
-	url = fmt.Sprintf("%s/%s", r.Config.PublishFilesPath, source)
+	url = r.assetUrl(AssetOriginPackage, source)
 
 	return
 
@@ -139,6 +159,11 @@ func (r *Renderer) MakeRenderFileParams(b *model.Block) (params *FileRenderParam
 		err = fmt.Errorf("file not found %s", file.TargetObjectId)
 		return
 	}
+	src, ok := r.sanitizeUrl(src)
+	if !ok {
+		err = fmt.Errorf("file url is not allowed %s", file.TargetObjectId)
+		return
+	}
 
 	details := r.findTargetDetails(file.TargetObjectId)
 	size := getRelationField(details, bundle.RelationKeySizeInBytes, relationToInt64)
@@ -146,10 +171,12 @@ func (r *Renderer) MakeRenderFileParams(b *model.Block) (params *FileRenderParam
 	sizeStr := prettyByteSize(size)
 
 	params = &FileRenderParams{
-		Id:   b.Id,
-		Src:  templ.URL(src),
-		Name: name,
-		Size: sizeStr,
+		Id:          b.Id,
+		Src:         templ.SafeURL(src),
+		Name:        name,
+		Description: getRelationField(details, bundle.RelationKeyDescription, relationToString),
+		Size:        sizeStr,
+		Source:      getRelationField(details, bundle.RelationKeySource, relationToString),
 	}
 
 	return
@@ -219,24 +246,36 @@ func (r *Renderer) RenderFile(b *model.Block) templ.Component {
 		width := GetWidth(b.Fields)
 
 		mediaParams := params.ToFileMediaRenderParams(width, classes)
+		r.fillMediaText(mediaParams, params)
 
 		var comp templ.Component
 		switch b.GetFile().GetType() {
 		case model.BlockContentFile_PDF:
+			mediaParams.Pages = r.pdfPageCount(b.GetFile().TargetObjectId)
 			blockParams := makeDefaultBlockParams(b)
 			blockParams.Content = FilePDFTemplate(r, mediaParams)
 			return BlockTemplate(r, blockParams)
 		case model.BlockContentFile_Image:
+			r.fillImageParams(mediaParams, b.GetFile().TargetObjectId)
+			if item, ok := r.Galleries[b.Id]; ok {
+				mediaParams.Gallery = &item
+			}
 			comp = ImageTemplate(mediaParams)
 		case model.BlockContentFile_Audio:
+			mediaParams.Audio = r.audioMeta(b.GetFile().TargetObjectId)
 			comp = AudioTemplate(mediaParams)
 		case model.BlockContentFile_Video:
+			mediaParams.Tracks = r.videoTracks(params.Source)
+			mediaParams.Poster = r.videoPoster(b.GetFile().TargetObjectId)
 			comp = VideoTemplate(mediaParams)
 		default:
 			fileTypeStr := b.GetFile().GetType().String()
 			log.Warn("file type is not supported", zap.String("type", fileTypeStr))
 			return NoneTemplate(fmt.Sprintf("file type is not supported: %s", fileTypeStr))
 		}
+		if mediaParams.Caption != "" {
+			comp = FigureTemplate(comp, mediaParams.Caption)
+		}
 
 		blockInnerParams := &BlockWrapperParams{
 			Classes:    []string{"wrap"},
@@ -0,0 +1,50 @@
+package renderer
+
+import (
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+)
+
+// GalleryItem places image block in a gallery, which lightbox pages through
+type GalleryItem struct {
+	// id of the first image block of the gallery
+	Gallery string
+	Index   int
+	Size    int
+}
+
+func isGalleryImage(b *model.Block) bool {
+	file := b.GetFile()
+	return file != nil && file.GetType() == model.BlockContentFile_Image && !isInlineLink(b)
+}
+
+// hydrateGalleriesInner groups consecutive images into galleries,
+// images of columns are consecutive with images around the columns
+func (r *Renderer) hydrateGalleriesInner(blocks []*model.Block) {
+	var images []*model.Block
+	flush := func() {
+		for i, b := range images {
+			r.Galleries[b.Id] = GalleryItem{Gallery: images[0].Id, Index: i, Size: len(images)}
+		}
+		images = nil
+	}
+
+	for _, b := range r.unwrapLayouts(blocks) {
+		// any other block breaks the gallery, as well as images which can't be shown
+		if isGalleryImage(b) {
+			if _, err := r.getFileUrl(b.GetFile().GetTargetObjectId()); err == nil {
+				images = append(images, b)
+				continue
+			}
+		}
+		flush()
+		if len(b.ChildrenIds) > 0 {
+			r.hydrateGalleriesInner(r.childBlocks(b))
+		}
+	}
+	flush()
+}
+
+func (r *Renderer) hydrateGalleries() {
+	r.Galleries = make(map[string]GalleryItem)
+	r.hydrateGalleriesInner(r.childBlocks(r.Root))
+}
@@ -0,0 +1,92 @@
+package renderer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newGalleryRenderer makes page of blocks, with a file object for every image
+func newGalleryRenderer(blocks ...*model.Block) *TestRenderer {
+	r := newPageRenderer(blocks...)
+	for _, b := range blocks {
+		if targetId := b.GetFile().GetTargetObjectId(); targetId != "" {
+			r.CachedPbFiles[filepath.Join("filesObjects", targetId+pbExt)] = imageObject(map[string]*types.Value{
+				bundle.RelationKeySource.String(): pbtypes.String("files/" + targetId + ".jpg"),
+			})
+		}
+	}
+	return r
+}
+
+func TestHydrateGalleries(t *testing.T) {
+	t.Run("consecutive images, including columns", func(t *testing.T) {
+		// given
+		column1 := &model.Block{Id: "column1", ChildrenIds: []string{"img3"}, Content: &model.BlockContentOfLayout{Layout: &model.BlockContentLayout{Style: model.BlockContentLayout_Column}}}
+		column2 := &model.Block{Id: "column2", ChildrenIds: []string{"img4"}, Content: &model.BlockContentOfLayout{Layout: &model.BlockContentLayout{Style: model.BlockContentLayout_Column}}}
+		row := &model.Block{Id: "row", ChildrenIds: []string{"column1", "column2"}, Content: &model.BlockContentOfLayout{Layout: &model.BlockContentLayout{Style: model.BlockContentLayout_Row}}}
+		img3, img4 := imageBlock("img3", "file3"), imageBlock("img4", "file4")
+		r := newGalleryRenderer(
+			imageBlock("img1", "file1"),
+			imageBlock("img2", "file2"),
+			textBlock("text", model.BlockContentText_Paragraph, "text"),
+			row,
+			imageBlock("img5", "file5"),
+		)
+		for _, b := range []*model.Block{column1, column2, img3, img4} {
+			r.BlocksById[b.Id] = b
+		}
+		r.CachedPbFiles[filepath.Join("filesObjects", "file3"+pbExt)] = imageObject(map[string]*types.Value{bundle.RelationKeySource.String(): pbtypes.String("files/3.jpg")})
+		r.CachedPbFiles[filepath.Join("filesObjects", "file4"+pbExt)] = imageObject(map[string]*types.Value{bundle.RelationKeySource.String(): pbtypes.String("files/4.jpg")})
+
+		// when
+		r.hydrateGalleries()
+
+		// then
+		assert.Equal(t, map[string]GalleryItem{
+			"img1": {Gallery: "img1", Index: 0, Size: 2},
+			"img2": {Gallery: "img1", Index: 1, Size: 2},
+			"img3": {Gallery: "img3", Index: 0, Size: 3},
+			"img4": {Gallery: "img3", Index: 1, Size: 3},
+			"img5": {Gallery: "img3", Index: 2, Size: 3},
+		}, r.Galleries)
+	})
+	t.Run("missing files and inline images break the gallery", func(t *testing.T) {
+		// given
+		inline := imageBlock("inline", "file3")
+		inline.GetFile().Style = model.BlockContentFile_Link
+		r := newGalleryRenderer(
+			imageBlock("img1", "file1"),
+			inline,
+			imageBlock("img2", "file2"),
+			imageBlock("missing", ""),
+			imageBlock("img3", "file4"),
+		)
+
+		// when
+		r.hydrateGalleries()
+
+		// then
+		assert.Equal(t, map[string]GalleryItem{
+			"img1": {Gallery: "img1", Index: 0, Size: 1},
+			"img2": {Gallery: "img2", Index: 0, Size: 1},
+			"img3": {Gallery: "img3", Index: 0, Size: 1},
+		}, r.Galleries)
+	})
+	t.Run("images are marked for lightbox", func(t *testing.T) {
+		// given
+		r := newGalleryRenderer(imageBlock("img1", "file1"), imageBlock("img2", "file2"))
+		r.hydrateGalleries()
+
+		// when
+		html := renderImage(t, r, r.BlocksById["img2"])
+
+		// then
+		assert.Contains(t, html, `tabindex="0" role="button" aria-haspopup="dialog" data-gallery="img1" data-gallery-index="1" data-gallery-size="2" data-full="/files/file2.jpg"`)
+	})
+}
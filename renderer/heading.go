@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+)
+
+// headings which get slug anchors
+var slugHeaderStyles = []model.BlockContentTextStyle{
+	model.BlockContentText_Header1,
+	model.BlockContentText_Header2,
+	model.BlockContentText_Header3,
+}
+
+// slugify makes a readable anchor from heading text:
+// lowercase letters and digits separated by single dashes
+func slugify(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(text) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			if dash && sb.Len() != 0 {
+				sb.WriteByte('-')
+			}
+			dash = false
+			sb.WriteRune(c)
+		} else {
+			dash = true
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "heading"
+	}
+	return sb.String()
+}
+
+// hydrateHeadingSlugs assigns unique slugs to headings in page order.
+// Repeated slugs get a numeric suffix: "intro", "intro-1", "intro-2".
+func (r *Renderer) hydrateHeadingSlugs() {
+	r.HeadingSlugs = make(map[string]string)
+	used := make(map[string]bool)
+
+	for _, b := range r.traverseBlocks(r.BlocksById, r.Root.GetId(), false) {
+		text := b.GetText()
+		if text == nil || !contains(slugHeaderStyles, text.GetStyle()) {
+			continue
+		}
+
+		base := slugify(text.GetText())
+		slug := base
+		for i := 1; used[slug] || r.BlocksById[slug] != nil; i++ {
+			slug = base + "-" + strconv.Itoa(i)
+		}
+		used[slug] = true
+		r.HeadingSlugs[b.Id] = slug
+	}
+}
+
+// headingSlug returns slug anchor of the heading block, the block id is kept as an alias
+func (r *Renderer) headingSlug(blockId string) string {
+	if r.HeadingSlugs == nil {
+		r.hydrateHeadingSlugs()
+	}
+	return r.HeadingSlugs[blockId]
+}
@@ -0,0 +1,116 @@
+package renderer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func textBlock(id string, style model.BlockContentTextStyle, text string) *model.Block {
+	return &model.Block{Id: id, Content: &model.BlockContentOfText{Text: &model.BlockContentText{Style: style, Text: text}}}
+}
+
+func newPageRenderer(blocks ...*model.Block) *TestRenderer {
+	root := &model.Block{Id: "root", Content: &model.BlockContentOfSmartblock{Smartblock: &model.BlockContentSmartblock{}}}
+	blocksById := map[string]*model.Block{"root": root}
+	for _, b := range blocks {
+		root.ChildrenIds = append(root.ChildrenIds, b.Id)
+		blocksById[b.Id] = b
+	}
+	r := NewTestRenderer(WithBlocksById(blocksById))
+	r.Root = root
+	return r
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Getting Started", "getting-started"},
+		{"  What's new in v2.0?  ", "what-s-new-in-v2-0"},
+		{"Привет, мир", "привет-мир"},
+		{"🚀 Launch", "launch"},
+		{"", "heading"},
+		{"!!!", "heading"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, slugify(tt.text))
+		})
+	}
+}
+
+func TestHydrateHeadingSlugs(t *testing.T) {
+	t.Run("duplicates get suffix", func(t *testing.T) {
+		// given
+		r := newPageRenderer(
+			textBlock("h1", model.BlockContentText_Header1, "Intro"),
+			textBlock("h2", model.BlockContentText_Header2, "Intro"),
+			textBlock("h3", model.BlockContentText_Header3, "Intro 1"),
+			textBlock("h4", model.BlockContentText_Header3, "intro"),
+			textBlock("p", model.BlockContentText_Paragraph, "Intro"),
+		)
+
+		// when
+		r.hydrateHeadingSlugs()
+
+		// then
+		assert.Equal(t, map[string]string{
+			"h1": "intro",
+			"h2": "intro-1",
+			"h3": "intro-1-1",
+			"h4": "intro-2",
+		}, r.HeadingSlugs)
+	})
+	t.Run("slug does not shadow block id", func(t *testing.T) {
+		// given
+		r := newPageRenderer(
+			textBlock("notes", model.BlockContentText_Paragraph, "text"),
+			textBlock("h1", model.BlockContentText_Header1, "Notes"),
+		)
+
+		// when
+		r.hydrateHeadingSlugs()
+
+		// then
+		assert.Equal(t, "notes-1", r.HeadingSlugs["h1"])
+	})
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	t.Run("heading has slug and permalink", func(t *testing.T) {
+		// given
+		heading := textBlock("h1", model.BlockContentText_Header2, "Getting Started")
+		r := newPageRenderer(heading)
+
+		// when
+		actual := r.makeTextBlockParams(heading)
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		pathAssertions := []pathAssertion{
+			{"div.flex > div.text > attrs[id]", "getting-started"},
+			{"div.flex > div.text > a.permalink > attrs[href]", "#getting-started"},
+		}
+		assertHtmlTag(t, tag, pathAssertions)
+		assert.Equal(t, "h1", actual.Id)
+	})
+	t.Run("table of contents links to slugs", func(t *testing.T) {
+		// given
+		r := newPageRenderer(textBlock("h1", model.BlockContentText_Header1, "Getting Started"))
+
+		// when
+		params := r.makeTableOfContentBlockParams(&model.Block{Id: "toc", Content: &model.BlockContentOfTableOfContents{}})
+
+		// then
+		builder := strings.Builder{}
+		err := params.Content.Render(context.Background(), &builder)
+		assert.NoError(t, err)
+		assert.Contains(t, builder.String(), `<a href="#getting-started">Getting Started</a>`)
+	})
+}
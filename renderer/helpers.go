@@ -11,8 +11,6 @@ import (
 	"github.com/gogo/protobuf/types"
 )
 
-const linkTemplate = "anytype://object?objectId=%s&spaceId=%s"
-
 func (r *Renderer) findWorkspaceDetails() (*types.Struct, error) {
 	for _, sn := range r.UberSp.PbFiles {
 		snapshot, err := readJsonpbSnapshot(sn)
@@ -45,12 +43,7 @@ func relationToString(field *types.Value) string {
 }
 
 func (r *Renderer) relationToEmojiUrl(emojiField *types.Value) string {
-	if emojiField.GetStringValue() != "" {
-		emojiRune := []rune(emojiField.GetStringValue())[0]
-		return r.GetEmojiUrl(emojiRune)
-	}
-
-	return ""
+	return r.GetEmojiUrl(relationToEmoji(emojiField))
 }
 
 func (r *Renderer) relationToFileUrl(imageField *types.Value) string {
@@ -124,22 +117,6 @@ func getRelationField[V relType](targetDetails *types.Struct, relationKey domain
 	return null
 }
 
-func (r *Renderer) makeAnytypeLink(targetDetails *types.Struct, targetObjectId string) string {
-	layout := getRelationField(targetDetails, bundle.RelationKeyLayout, relationToObjectTypeLayout)
-	switch layout {
-	case model.ObjectType_file, model.ObjectType_image, model.ObjectType_pdf, model.ObjectType_audio, model.ObjectType_video:
-		src, err := r.getFileUrl(targetObjectId)
-		if err != nil {
-			log.Error("failed to get file url", zap.Error(err))
-			return ""
-		}
-		return src
-	default:
-		spaceId := getRelationField(targetDetails, bundle.RelationKeySpaceId, relationToString)
-		return fmt.Sprintf(linkTemplate, targetObjectId, spaceId)
-	}
-}
-
 func (r *Renderer) resolveObjectLayout(details *types.Struct) model.ObjectTypeLayout {
 	_, ok := details.GetFields()[bundle.RelationKeyResolvedLayout.String()]
 	if ok {
@@ -0,0 +1,314 @@
+// Package htmlsanitizer filters html from published content
+// down to an allowlist of tags, attributes and hosts.
+package htmlsanitizer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Policy describes html allowed in the output
+type Policy struct {
+	// allowed tags, other tags are removed keeping their inner content,
+	// except for the ones which are dangerous as a whole (script, style, object, ...)
+	Tags []string
+	// allowed attributes by tag, "*" key is for attributes allowed on every tag.
+	// Trailing "*" matches by prefix, e.g. "data-*". Event handlers are never allowed.
+	Attributes map[string][]string
+	// hosts, including their subdomains, allowed in <iframe src>
+	IframeHosts []string
+	// hosts, including their subdomains, allowed in <script src>.
+	// Inline scripts are never allowed.
+	ScriptSources []string
+	// schemes allowed in url attributes, DefaultUrlSchemes if empty
+	UrlSchemes []string
+}
+
+// Report lists what was removed by Sanitize
+type Report struct {
+	// removed elements, "!--" for comments
+	Tags []string
+	// removed attributes as "tag[attr]"
+	Attributes []string
+}
+
+func (r *Report) Empty() bool {
+	return len(r.Tags) == 0 && len(r.Attributes) == 0
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf("tags: %v, attributes: %v", r.Tags, r.Attributes)
+}
+
+func (r *Report) addTag(tag string) {
+	if !slices.Contains(r.Tags, tag) {
+		r.Tags = append(r.Tags, tag)
+	}
+}
+
+func (r *Report) addAttribute(tag, attr string) {
+	name := tag + "[" + attr + "]"
+	if !slices.Contains(r.Attributes, name) {
+		r.Attributes = append(r.Attributes, name)
+	}
+}
+
+// removed together with everything inside them, even if listed in Policy.Tags
+var dropWithContent = []string{
+	"applet", "base", "embed", "frame", "frameset", "head", "link", "math", "meta",
+	"noembed", "noframes", "noscript", "object", "plaintext", "style", "template",
+	"textarea", "title", "xmp",
+}
+
+var voidTags = []string{
+	"area", "br", "col", "hr", "img", "input", "source", "track", "wbr",
+}
+
+var urlAttributes = []string{
+	"action", "background", "cite", "data", "formaction", "href", "longdesc",
+	"poster", "src", "xlink:href",
+}
+
+var unsafeStyle = regexp.MustCompile(`(?i)expression|javascript|vbscript|behavior|binding|url\s*\(|@import|\\`)
+
+// Sanitize filters input down to the policy
+// and reports every tag and attribute which was removed
+func (p *Policy) Sanitize(input string) (string, *Report) {
+	var (
+		sb     strings.Builder
+		report = &Report{}
+		// tag which is skipped with its content and its nesting depth
+		skipTag   string
+		skipDepth int
+		// script or iframe, which content is raw text for the tokenizer
+		rawTag string
+	)
+
+	z := xhtml.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				report.addTag("#error")
+			}
+			break
+		}
+		tok := z.Token()
+
+		if skipTag != "" {
+			switch {
+			case tt == xhtml.StartTagToken && tok.Data == skipTag:
+				skipDepth++
+			case tt == xhtml.EndTagToken && tok.Data == skipTag:
+				skipDepth--
+				if skipDepth == 0 {
+					skipTag = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			// iframe fallback content is not shown by browsers
+			if rawTag != "" {
+				if rawTag == "script" && strings.TrimSpace(tok.Data) != "" {
+					report.addTag("script#inline")
+				}
+				continue
+			}
+			sb.WriteString(escapeText(tok.Data))
+
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			attrs, ok := p.allowTag(tok, report)
+			if !ok {
+				report.addTag(tok.Data)
+				if tt == xhtml.StartTagToken && p.dropsContent(tok.Data) {
+					skipTag = tok.Data
+					skipDepth = 1
+				}
+				continue
+			}
+
+			writeStartTag(&sb, tok.Data, attrs)
+			switch {
+			case slices.Contains(voidTags, tok.Data):
+			case tt == xhtml.SelfClosingTagToken:
+				sb.WriteString("</" + tok.Data + ">")
+			case tok.Data == "script" || tok.Data == "iframe":
+				rawTag = tok.Data
+			}
+
+		case xhtml.EndTagToken:
+			if tok.Data == "script" || tok.Data == "iframe" {
+				if rawTag == tok.Data {
+					rawTag = ""
+					sb.WriteString("</" + tok.Data + ">")
+				}
+				continue
+			}
+			if p.allowsTag(tok.Data) && !slices.Contains(voidTags, tok.Data) {
+				sb.WriteString("</" + tok.Data + ">")
+			}
+
+		case xhtml.CommentToken:
+			report.addTag("!--")
+
+		case xhtml.DoctypeToken:
+			report.addTag("!doctype")
+		}
+	}
+
+	return sb.String(), report
+}
+
+func (p *Policy) allowsTag(tag string) bool {
+	if slices.Contains(dropWithContent, tag) {
+		return false
+	}
+	switch tag {
+	case "script":
+		return len(p.ScriptSources) != 0
+	case "iframe":
+		return len(p.IframeHosts) != 0 && slices.Contains(p.Tags, tag)
+	}
+	return slices.Contains(p.Tags, tag)
+}
+
+// scripts and iframes which failed the check are removed completely,
+// there is no meaningful text inside
+func (p *Policy) dropsContent(tag string) bool {
+	return tag == "script" || tag == "iframe" || slices.Contains(dropWithContent, tag)
+}
+
+// allowTag checks tag with its attributes
+// and returns the attributes left after filtering
+func (p *Policy) allowTag(tok xhtml.Token, report *Report) ([]xhtml.Attribute, bool) {
+	tag := tok.Data
+	if !p.allowsTag(tag) {
+		return nil, false
+	}
+
+	var attrs []xhtml.Attribute
+	seen := map[string]bool{}
+	for _, attr := range tok.Attr {
+		key := attr.Key
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + attr.Key
+		}
+		// browsers take the first one of duplicated attributes
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if !p.allowAttribute(tag, key, attr.Val) {
+			report.addAttribute(tag, key)
+			continue
+		}
+		attrs = append(attrs, xhtml.Attribute{Key: key, Val: attr.Val})
+	}
+
+	// src decides whether script or iframe is loaded at all
+	switch tag {
+	case "script":
+		src, ok := attrValue(attrs, "src")
+		if !ok || !matchHost(src, p.ScriptSources, p.urlSchemes()) {
+			return nil, false
+		}
+	case "iframe":
+		src, ok := attrValue(attrs, "src")
+		if !ok || !matchHost(src, p.IframeHosts, p.urlSchemes()) {
+			return nil, false
+		}
+	}
+
+	return attrs, true
+}
+
+func (p *Policy) allowAttribute(tag, key, value string) bool {
+	if strings.HasPrefix(key, "on") {
+		return false
+	}
+	if !matchAttribute(p.Attributes[tag], key) && !matchAttribute(p.Attributes["*"], key) {
+		// src of scripts is checked against ScriptSources instead
+		if !(tag == "script" && key == "src") {
+			return false
+		}
+	}
+
+	if slices.Contains(urlAttributes, key) {
+		_, ok := SanitizeUrl(value, p.urlSchemes())
+		return ok
+	}
+	if key == "srcset" {
+		for _, candidate := range strings.Split(value, ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) == 0 {
+				continue
+			}
+			if _, ok := SanitizeUrl(fields[0], p.urlSchemes()); !ok {
+				return false
+			}
+		}
+	}
+	if key == "style" {
+		return !unsafeStyle.MatchString(value)
+	}
+
+	return true
+}
+
+func (p *Policy) urlSchemes() []string {
+	if len(p.UrlSchemes) != 0 {
+		return p.UrlSchemes
+	}
+	return DefaultUrlSchemes
+}
+
+func matchAttribute(allowed []string, key string) bool {
+	for _, a := range allowed {
+		if a == key {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "*"); ok && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func attrValue(attrs []xhtml.Attribute, key string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// newlines are normalized, escaped carriage returns would be unescaped into
+// raw ones, which are parsed as newlines the next time
+var newlineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func escapeText(text string) string {
+	return html.EscapeString(newlineReplacer.Replace(text))
+}
+
+func writeStartTag(sb *strings.Builder, tag string, attrs []xhtml.Attribute) {
+	sb.WriteString("<" + tag)
+	for _, attr := range attrs {
+		sb.WriteString(" " + attr.Key)
+		if attr.Val != "" {
+			sb.WriteString(`="` + escapeText(attr.Val) + `"`)
+		}
+	}
+	sb.WriteString(">")
+}
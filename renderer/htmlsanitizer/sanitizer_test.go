@@ -0,0 +1,311 @@
+package htmlsanitizer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	xhtml "golang.org/x/net/html"
+)
+
+var testPolicy = &Policy{
+	Tags: []string{"div", "p", "a", "img", "iframe", "blockquote"},
+	Attributes: map[string][]string{
+		"*":      {"class", "style", "data-*"},
+		"a":      {"href"},
+		"img":    {"src", "srcset", "alt"},
+		"iframe": {"src", "width", "height"},
+		"script": {"async"},
+	},
+	IframeHosts:   []string{"youtube.com"},
+	ScriptSources: []string{"telegram.org"},
+}
+
+// xssPayloads are common filter evasion vectors, none of them may survive sanitizing
+var xssPayloads = []string{
+	`<script>alert(1)</script>`,
+	`<SCRIPT SRC=https://evil.com/xss.js></SCRIPT>`,
+	`<script src="https://telegram.org.evil.com/x.js"></script>`,
+	`<script src="https://telegram.org/widget.js">alert(1)</script>`,
+	`<img src=x onerror=alert(1)>`,
+	`<img src="javascript:alert(1)">`,
+	`<img src=" java	script:alert(1)">`,
+	`<img srcset="https://example.com/a.png 1x, javascript:alert(1) 2x">`,
+	`<IMG SRC=JaVaScRiPt:alert(1)>`,
+	`<img """><script>alert(1)</script>">`,
+	`<a href="javascript:alert(1)">x</a>`,
+	`<a href="&#106;avascript:alert(1)">x</a>`,
+	`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+	`<div onmouseover="alert(1)">x</div>`,
+	`<div style="background:url(javascript:alert(1))">x</div>`,
+	`<div style="width: expression(alert(1))">x</div>`,
+	`<svg onload=alert(1)>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<math><mtext><script>alert(1)</script></mtext></math>`,
+	`<iframe src="javascript:alert(1)"></iframe>`,
+	`<iframe src="https://evil.com"></iframe>`,
+	`<iframe srcdoc="<script>alert(1)</script>" src="https://youtube.com/embed/x"></iframe>`,
+	`<object data="javascript:alert(1)"></object>`,
+	`<embed src="javascript:alert(1)">`,
+	`<style>body{background:url(javascript:alert(1))}</style>`,
+	`<base href="javascript:alert(1)//">`,
+	`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+	`<form action="javascript:alert(1)"><button>x</button></form>`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`,
+	`<textarea><script>alert(1)</script></textarea>`,
+	`<!--<script>alert(1)</script>-->`,
+	`<div <script>alert(1)</script>>`,
+	`<p>text</p><scr<script>ipt>alert(1)</script>`,
+	`<a href="https://example.com" onclick="alert(1)" onclick="x">x</a>`,
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		expected   string
+		tags       []string
+		attributes []string
+	}{
+		{
+			name:     "allowed markup",
+			input:    `<div class="a"><p>text <a href="https://example.com">link</a></p></div>`,
+			expected: `<div class="a"><p>text <a href="https://example.com">link</a></p></div>`,
+		},
+		{
+			name:     "text is escaped",
+			input:    `a < b & c`,
+			expected: `a &lt; b &amp; c`,
+		},
+		{
+			name:     "unknown tag keeps content",
+			input:    `<section><p>text</p></section>`,
+			expected: `<p>text</p>`,
+			tags:     []string{"section"},
+		},
+		{
+			name:     "inline script",
+			input:    `<p>a</p><script>alert(1)</script><p>b</p>`,
+			expected: `<p>a</p><p>b</p>`,
+			tags:     []string{"script"},
+		},
+		{
+			name:     "allowed script source",
+			input:    `<script async src="https://telegram.org/js/telegram-widget.js" data-telegram-post="a/1"></script>`,
+			expected: `<script async src="https://telegram.org/js/telegram-widget.js" data-telegram-post="a/1"></script>`,
+		},
+		{
+			name:     "script source on subdomain",
+			input:    `<script src="https://core.telegram.org/widget.js"></script>`,
+			expected: `<script src="https://core.telegram.org/widget.js"></script>`,
+		},
+		{
+			name:     "script source on other host",
+			input:    `<script src="https://evil.com/telegram.org.js"></script>`,
+			expected: ``,
+			tags:     []string{"script"},
+		},
+		{
+			name:     "allowed iframe",
+			input:    `<iframe src="https://www.youtube.com/embed/x" width="100"></iframe>`,
+			expected: `<iframe src="https://www.youtube.com/embed/x" width="100"></iframe>`,
+		},
+		{
+			name:       "iframe srcdoc",
+			input:      `<iframe src="https://www.youtube.com/embed/x" srcdoc="<b>x</b>"></iframe>`,
+			expected:   `<iframe src="https://www.youtube.com/embed/x"></iframe>`,
+			attributes: []string{"iframe[srcdoc]"},
+		},
+		{
+			name:     "iframe from other host",
+			input:    `<iframe src="https://evil.com/"><p>fallback</p></iframe>`,
+			expected: ``,
+			tags:     []string{"iframe"},
+		},
+		{
+			name:       "event handler",
+			input:      `<img src="https://example.com/a.png" onerror="alert(1)">`,
+			expected:   `<img src="https://example.com/a.png">`,
+			attributes: []string{"img[onerror]"},
+		},
+		{
+			name:       "javascript href",
+			input:      `<a href="javascript:alert(1)">x</a>`,
+			expected:   `<a>x</a>`,
+			attributes: []string{"a[href]"},
+		},
+		{
+			name:       "unsafe style",
+			input:      `<p style="background: url(https://evil.com)">x</p>`,
+			expected:   `<p>x</p>`,
+			attributes: []string{"p[style]"},
+		},
+		{
+			name:     "safe style",
+			input:    `<p style="color: red">x</p>`,
+			expected: `<p style="color: red">x</p>`,
+		},
+		{
+			name:     "dangerous tag with content",
+			input:    `<style>p{}</style><object><p>x</p></object><p>y</p>`,
+			expected: `<p>y</p>`,
+			tags:     []string{"style", "object"},
+		},
+		{
+			name:     "comment",
+			input:    `<p>a</p><!-- secret -->`,
+			expected: `<p>a</p>`,
+			tags:     []string{"!--"},
+		},
+		{
+			name:     "carriage returns",
+			input:    "<p class=\"a&#13;b\">a&#13;b\r\nc</p>",
+			expected: "<p class=\"a\nb\">a\nb\nc</p>",
+		},
+		{
+			name:     "self-closing tag",
+			input:    `<div/><img src="a.png"/>`,
+			expected: `<div></div><img src="a.png">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			out, report := testPolicy.Sanitize(tt.input)
+
+			// then
+			assert.Equal(t, tt.expected, out)
+			assert.Equal(t, tt.tags, report.Tags)
+			assert.Equal(t, tt.attributes, report.Attributes)
+		})
+	}
+}
+
+func TestSanitizeXssPayloads(t *testing.T) {
+	for _, payload := range xssPayloads {
+		t.Run(payload, func(t *testing.T) {
+			out, report := testPolicy.Sanitize(payload)
+
+			assertSafe(t, out)
+			assert.False(t, report.Empty())
+		})
+	}
+}
+
+func TestSanitizeEmptyPolicy(t *testing.T) {
+	out, report := (&Policy{}).Sanitize(`<p onclick="x">a <b>b</b></p><script src="https://telegram.org/a.js"></script>`)
+
+	assert.Equal(t, `a b`, out)
+	assert.Equal(t, []string{"p", "b", "script"}, report.Tags)
+}
+
+func FuzzSanitize(f *testing.F) {
+	for _, payload := range xssPayloads {
+		f.Add(payload)
+	}
+	f.Add(`<iframe src="https://www.youtube.com/embed/x"></iframe>`)
+	f.Add(`<script async src="https://telegram.org/js/telegram-widget.js"></script>`)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		out, _ := testPolicy.Sanitize(input)
+
+		assertSafe(t, out)
+
+		// sanitized output is already within the policy
+		again, report := testPolicy.Sanitize(out)
+		if again != out {
+			t.Errorf("sanitize is not idempotent:\n%q\n%q", out, again)
+		}
+		if !report.Empty() {
+			t.Errorf("sanitized output was sanitized again: %s", report)
+		}
+	})
+}
+
+var urlSchemeRe = regexp.MustCompile(`^([a-z][a-z0-9+.-]*):`)
+
+// browserUrlScheme returns scheme which browsers resolve url with, empty for relative urls
+func browserUrlScheme(rawUrl string) string {
+	u := strings.TrimFunc(rawUrl, func(r rune) bool {
+		return r <= ' '
+	})
+	u = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(strings.ToLower(u))
+	if m := urlSchemeRe.FindStringSubmatch(u); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// assertSafe parses html the way browsers do and checks
+// that nothing executable is left in it
+func assertSafe(t *testing.T, out string) {
+	t.Helper()
+	z := xhtml.NewTokenizer(strings.NewReader(out))
+	inScript := false
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			return
+		}
+		tok := z.Token()
+		switch tt {
+		case xhtml.TextToken:
+			if inScript && strings.TrimSpace(tok.Data) != "" {
+				t.Errorf("inline script in %q", out)
+			}
+		case xhtml.EndTagToken:
+			if tok.Data == "script" {
+				inScript = false
+			}
+		case xhtml.CommentToken:
+			t.Errorf("comment in %q", out)
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if !testPolicy.allowsTag(tok.Data) {
+				t.Errorf("tag %s in %q", tok.Data, out)
+			}
+			src := ""
+			for _, attr := range tok.Attr {
+				if strings.HasPrefix(attr.Key, "on") || attr.Key == "srcdoc" {
+					t.Errorf("attribute %s in %q", attr.Key, out)
+				}
+				if attr.Key == "src" {
+					src = attr.Val
+				}
+				var urls []string
+				switch {
+				case slices.Contains(urlAttributes, attr.Key):
+					urls = []string{attr.Val}
+				case attr.Key == "srcset":
+					for _, candidate := range strings.Split(attr.Val, ",") {
+						if fields := strings.Fields(candidate); len(fields) != 0 {
+							urls = append(urls, fields[0])
+						}
+					}
+				case attr.Key == "style" && strings.Contains(strings.ToLower(attr.Val), "javascript"):
+					t.Errorf("unsafe style %q in %q", attr.Val, out)
+				}
+				for _, u := range urls {
+					if scheme := browserUrlScheme(u); scheme != "" && !slices.Contains(DefaultUrlSchemes, scheme) {
+						t.Errorf("unsafe url in %s=%q in %q", attr.Key, attr.Val, out)
+					}
+				}
+			}
+			switch tok.Data {
+			case "script":
+				if tt == xhtml.StartTagToken {
+					inScript = true
+				}
+				if !matchHost(src, testPolicy.ScriptSources, DefaultUrlSchemes) {
+					t.Errorf("script from %q in %q", src, out)
+				}
+			case "iframe":
+				if !matchHost(src, testPolicy.IframeHosts, DefaultUrlSchemes) {
+					t.Errorf("iframe from %q in %q", src, out)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package htmlsanitizer
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultUrlSchemes is used when Policy.UrlSchemes is empty
+var DefaultUrlSchemes = []string{"http", "https"}
+
+// SanitizeUrl returns a cleaned up rawUrl and true if it is relative
+// or its scheme is one of allowedSchemes.
+//
+// Leading and trailing control characters and spaces, and tabs and newlines
+// anywhere, are dropped the same way browsers do before resolving a scheme,
+// so ` javascript:` and `java\tscript:` are recognized too.
+func SanitizeUrl(rawUrl string, allowedSchemes []string) (string, bool) {
+	cleanUrl := strings.TrimFunc(rawUrl, func(r rune) bool {
+		return r <= ' '
+	})
+	cleanUrl = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(cleanUrl)
+
+	if cleanUrl == "" {
+		return "", false
+	}
+
+	parsedUrl, err := url.Parse(cleanUrl)
+	if err != nil {
+		return "", false
+	}
+
+	// relative urls inherit the page scheme
+	if parsedUrl.Scheme == "" {
+		return cleanUrl, true
+	}
+
+	if !slices.Contains(allowedSchemes, strings.ToLower(parsedUrl.Scheme)) {
+		return "", false
+	}
+
+	return cleanUrl, true
+}
+
+// matchHost reports whether absolute rawUrl points to one of hosts or their subdomains
+func matchHost(rawUrl string, hosts []string, allowedSchemes []string) bool {
+	cleanUrl, ok := SanitizeUrl(rawUrl, allowedSchemes)
+	if !ok {
+		return false
+	}
+
+	parsedUrl, err := url.Parse(cleanUrl)
+	if err != nil || parsedUrl.Scheme == "" {
+		return false
+	}
+
+	host := strings.ToLower(parsedUrl.Hostname())
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
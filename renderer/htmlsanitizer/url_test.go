@@ -0,0 +1,48 @@
+package htmlsanitizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+		ok       bool
+	}{
+		{"https", "https://example.com/a?b=c#d", "https://example.com/a?b=c#d", true},
+		{"http", "http://example.com", "http://example.com", true},
+		{"mailto", "mailto:a@example.com", "mailto:a@example.com", true},
+		{"tel", "tel:+12345", "tel:+12345", true},
+		{"anytype", "anytype://object?objectId=id&spaceId=space", "anytype://object?objectId=id&spaceId=space", true},
+		{"uppercase scheme", "HTTPS://example.com", "HTTPS://example.com", true},
+		{"relative path", "files/image.png", "files/image.png", true},
+		{"fragment", "#heading", "#heading", true},
+		{"surrounding spaces", "  https://example.com\n", "https://example.com", true},
+		{"empty", "", "", false},
+		{"only spaces", " \t ", "", false},
+		{"javascript", "javascript:alert(1)", "", false},
+		{"javascript mixed case", "JaVaScRiPt:alert(1)", "", false},
+		{"javascript leading space", " javascript:alert(1)", "", false},
+		{"javascript leading control char", "\x01javascript:alert(1)", "", false},
+		{"javascript with tab", "java\tscript:alert(1)", "", false},
+		{"javascript with newline", "java\nscript:alert(1)", "", false},
+		{"vbscript", "vbscript:msgbox(1)", "", false},
+		{"data", "data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==", "", false},
+		{"file", "file:///etc/passwd", "", false},
+		{"invalid", "::::", "", false},
+		{"control char inside", "https://exa\x00mple.com", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, ok := SanitizeUrl(tt.url, []string{"http", "https", "mailto", "tel", "anytype"})
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
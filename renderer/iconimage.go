@@ -36,7 +36,7 @@ func pageIconInitSize(layout model.ObjectTypeLayout) int32 {
 func (r *Renderer) RenderPageIconImage() templ.Component {
 	details := r.Sp.Snapshot.Data.GetDetails()
 	layout := r.ResolvedLayout
-	iconEmoji := getRelationField(details, bundle.RelationKeyIconEmoji, r.relationToEmojiUrl)
+	iconEmoji := getRelationField(details, bundle.RelationKeyIconEmoji, relationToEmoji)
 	iconImage := getRelationField(details, bundle.RelationKeyIconImage, r.relationToFileUrl)
 
 	if isTodoLayout(layout) || isBookmarkLayout(layout) {
@@ -52,7 +52,7 @@ func (r *Renderer) RenderPageIconImage() templ.Component {
 		Size:      pageIconInitSize(layout),
 	})
 
-	if params.Src == "" {
+	if params.Src == "" && params.Emoji == "" {
 		return NoneTemplate("")
 	}
 
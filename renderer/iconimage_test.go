@@ -12,6 +12,9 @@ import (
 	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/anytype-publish-renderer/utils"
 )
 
 func TestMakeRenderPageIconImageParams(t *testing.T) {
@@ -40,6 +43,82 @@ func TestMakeRenderPageIconImageParams(t *testing.T) {
 		assert.Equal(t, expected.Src, actual.Src)
 	})
 
+	t.Run("icon image emoji zwj sequence", func(t *testing.T) {
+		r := NewTestRenderer(
+			WithRootSnapshot(&pb.SnapshotWithType{
+				Snapshot: &pb.ChangeSnapshot{
+					Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{
+							Fields: map[string]*types.Value{
+								bundle.RelationKeyIconEmoji.String(): pbtypes.String("👩🏽‍💻"),
+							},
+						},
+					},
+				},
+			}),
+			WithConfig(RenderConfig{EmojiMode: EmojiModeLocal, EmojiAssetsPath: "/static/emoji"}),
+		)
+
+		actual := r.MakeRenderIconObjectParams(r.Sp.GetSnapshot().GetData().GetDetails(), &IconObjectProps{
+			NoDefault: true,
+			Size:      pageIconInitSize(model.ObjectType_basic),
+		})
+		assert.Equal(t, "/static/emoji/1f469-1f3fd-200d-1f4bb.png", actual.Src)
+	})
+
+	t.Run("icon image emoji native", func(t *testing.T) {
+		r := NewTestRenderer(
+			WithRootSnapshot(&pb.SnapshotWithType{
+				Snapshot: &pb.ChangeSnapshot{
+					Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{
+							Fields: map[string]*types.Value{
+								bundle.RelationKeyIconEmoji.String(): pbtypes.String("🇺🇦"),
+							},
+						},
+					},
+				},
+			}),
+			WithConfig(RenderConfig{EmojiMode: EmojiModeNative}),
+		)
+
+		actual := r.MakeRenderIconObjectParams(r.Sp.GetSnapshot().GetData().GetDetails(), &IconObjectProps{
+			NoDefault: true,
+			Size:      pageIconInitSize(model.ObjectType_basic),
+		})
+		assert.Empty(t, actual.Src)
+		assert.Equal(t, "🇺🇦", actual.Emoji)
+		assert.Contains(t, actual.IconClasses, "smileNative")
+	})
+
+	t.Run("icon image emoji in single file", func(t *testing.T) {
+		r := NewTestRenderer(
+			WithRootSnapshot(&pb.SnapshotWithType{
+				Snapshot: &pb.ChangeSnapshot{
+					Data: &model.SmartBlockSnapshotBase{
+						Details: &types.Struct{
+							Fields: map[string]*types.Value{
+								bundle.RelationKeyIconEmoji.String(): pbtypes.String("🇺🇦"),
+							},
+						},
+					},
+				},
+			}),
+			WithConfig(RenderConfig{AnytypeCdnUrl: "https://cdn", SingleFile: &SingleFileConfig{}}),
+		)
+
+		actual := r.MakeRenderIconObjectParams(r.Sp.GetSnapshot().GetData().GetDetails(), &IconObjectProps{
+			NoDefault: true,
+			Size:      pageIconInitSize(model.ObjectType_basic),
+		})
+		html, err := utils.TemplToString(IconObjectTemplate(r.Renderer, actual))
+		require.NoError(t, err)
+
+		assert.Empty(t, actual.Src)
+		assert.Equal(t, "🇺🇦", actual.Emoji)
+		assert.Contains(t, html, "🇺🇦")
+	})
+
 	t.Run("icon image uploaded", func(t *testing.T) {
 		r := NewTestRenderer(
 			WithRootSnapshot(&pb.SnapshotWithType{
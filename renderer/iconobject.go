@@ -71,6 +71,8 @@ type IconObjectParams struct {
 	Src         string
 	SvgSrc      string
 	SvgColor    string
+	// emoji text, set instead of Src in EmojiModeNative
+	Emoji string
 }
 
 type IconObjectProps struct {
@@ -284,7 +286,7 @@ func (r *Renderer) getDefaultIconPath(objectDetails *types.Struct, name string)
 }
 
 func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props *IconObjectProps) (params *IconObjectParams) {
-	var src, svgSrc, svgColor string
+	var src, svgSrc, svgColor, emoji string
 	classes := []string{"iconObject"}
 	var iconClasses []string
 	var isDeleted bool
@@ -293,7 +295,7 @@ func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props
 	}
 
 	layout := r.resolveObjectLayout(targetDetails)
-	iconEmoji := getRelationField(targetDetails, bundle.RelationKeyIconEmoji, r.relationToEmojiUrl)
+	iconEmoji := getRelationField(targetDetails, bundle.RelationKeyIconEmoji, relationToEmoji)
 	iconImage := getRelationField(targetDetails, bundle.RelationKeyIconImage, r.relationToFileUrl)
 	hasIconEmoji := iconEmoji != ""
 	hasIconImage := iconImage != ""
@@ -312,7 +314,10 @@ func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props
 	case model.ObjectType_basic:
 		if hasIconEmoji {
 			iconClasses = append(iconClasses, "smileImage")
-			src = iconEmoji
+			src, emoji = r.emojiIcon(iconEmoji)
+			if emoji != "" {
+				iconClasses = append(iconClasses, "smileNative")
+			}
 		} else if hasIconImage {
 			classes = append(classes, "withImage")
 			iconClasses = append(iconClasses, "iconImage")
@@ -371,7 +376,10 @@ func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props
 		}
 		if hasIconEmoji {
 			iconClasses = append(iconClasses, "smileImage")
-			src = iconEmoji
+			src, emoji = r.emojiIcon(iconEmoji)
+			if emoji != "" {
+				iconClasses = append(iconClasses, "smileNative")
+			}
 		} else {
 			iconName := getRelationField(targetDetails, bundle.RelationKeyIconName, relationToString)
 			if iconName == "" {
@@ -445,8 +453,15 @@ func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props
 		iconClasses = append(iconClasses, fmt.Sprintf("c%d", iconSize))
 	}
 
+	// icons are small, so the smallest derivative sharp on retina screens is enough
+	if hasIconImage && src == iconImage && !isDeleted {
+		iconImageId := getRelationField(targetDetails, bundle.RelationKeyIconImage, relationToString)
+		src = r.smallImageSrc(iconImageId, src, 2*int(max(iconSize, props.Size)))
+	}
+
 	if isDeleted {
 		src = r.GetStaticFolderUrl("/img/icon/ghost.svg")
+		emoji = ""
 		iconClasses = []string{"iconCommon"}
 		if iconSize != 0 {
 			iconClasses = append(iconClasses, fmt.Sprintf("c%d", iconSize))
@@ -459,7 +474,16 @@ func (r *Renderer) MakeRenderIconObjectParams(targetDetails *types.Struct, props
 		Src:         src,
 		SvgSrc:      svgSrc,
 		SvgColor:    svgColor,
+		Emoji:       emoji,
+	}
+}
+
+// emojiIcon returns either image url or the emoji itself for EmojiModeNative
+func (r *Renderer) emojiIcon(emoji string) (src string, text string) {
+	if r.emojiMode() == EmojiModeNative {
+		return "", emoji
 	}
+	return r.GetEmojiUrl(emoji), ""
 }
 
 func makeSpaceSvgIcon(targetDetails *types.Struct, size int) string {
@@ -0,0 +1,162 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"go.uber.org/zap"
+)
+
+// width of page content, images are never shown wider on desktop
+const contentWidth = 704
+
+// ImageInfo describes image file of the package
+type ImageInfo struct {
+	ObjectId string
+	// url of the original
+	Src string
+	// path of the original on disk, empty if package is not local
+	Path string
+	// 0 if unknown
+	Width  int
+	Height int
+}
+
+// ImageDerivative is a resized copy of an image
+type ImageDerivative struct {
+	Src   string
+	Width int
+}
+
+// ImageDerivativesFunc returns resized copies of the image for srcset, nil if there are none
+type ImageDerivativesFunc func(img ImageInfo) []ImageDerivative
+
+// localFilePath returns path of package file on disk, empty if package is fetched over http.
+// Source comes from the package, so paths leading outside of PublishFilesPath are not allowed
+func (r *Renderer) localFilePath(source string) string {
+	if source == "" || strings.HasPrefix(r.Config.PublishFilesPath, "http") {
+		return ""
+	}
+	root := filepath.Clean(r.Config.PublishFilesPath)
+	path := filepath.Join(root, filepath.FromSlash(source))
+	if !isInsideDir(root, path) {
+		log.Warn("file source is outside of files path", zap.String("source", source))
+		return ""
+	}
+	return path
+}
+
+// readImageSize reads dimensions from image header
+func readImageSize(path string) (width, height int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// imageInfo collects image dimensions from file object details, or from image header if details have none
+func (r *Renderer) imageInfo(objectId, src string) ImageInfo {
+	info := ImageInfo{ObjectId: objectId, Src: src}
+	details := r.findTargetDetails(objectId)
+	info.Width = int(pbtypes.GetFloat64(details, bundle.RelationKeyWidthInPixels.String()))
+	info.Height = int(pbtypes.GetFloat64(details, bundle.RelationKeyHeightInPixels.String()))
+	info.Path = r.localFilePath(pbtypes.GetString(details, bundle.RelationKeySource.String()))
+
+	if (info.Width == 0 || info.Height == 0) && info.Path != "" {
+		if width, height, err := readImageSize(info.Path); err == nil {
+			info.Width, info.Height = width, height
+		}
+	}
+	return info
+}
+
+// derivatives are separate files, single file pages only have originals inlined
+func (r *Renderer) useDerivatives() bool {
+	return r.Config.ImageDerivatives != nil && !r.isSingleFile()
+}
+
+// imageSrcset makes srcset of derivatives and the original, empty if there are no derivatives
+func (r *Renderer) imageSrcset(info ImageInfo) string {
+	if !r.useDerivatives() || info.Width == 0 {
+		return ""
+	}
+	derivatives := r.Config.ImageDerivatives(info)
+	derivatives = slices.DeleteFunc(slices.Clone(derivatives), func(d ImageDerivative) bool {
+		return d.Width <= 0 || d.Width >= info.Width
+	})
+	if len(derivatives) == 0 {
+		return ""
+	}
+	slices.SortFunc(derivatives, func(a, b ImageDerivative) int {
+		return a.Width - b.Width
+	})
+
+	var candidates []string
+	for _, d := range append(derivatives, ImageDerivative{Src: info.Src, Width: info.Width}) {
+		src, ok := r.sanitizeUrl(d.Src)
+		// commas and spaces separate srcset candidates
+		if !ok || strings.ContainsAny(src, ", ") {
+			continue
+		}
+		candidates = append(candidates, fmt.Sprintf("%s %dw", src, d.Width))
+	}
+	if len(candidates) < 2 {
+		return ""
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// imageSizes tells browser how wide image is shown, block width is a percent of content width
+func imageSizes(width string) string {
+	fraction := 1.0
+	if percent, err := strconv.Atoi(strings.TrimSuffix(width, "%")); err == nil && percent > 0 && percent < 100 {
+		fraction = float64(percent) / 100
+	}
+	return fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", contentWidth+32, int(math.Round(contentWidth*fraction)))
+}
+
+func (r *Renderer) fillImageParams(params *FileMediaRenderParams, objectId string) {
+	info := r.imageInfo(objectId, string(params.Src))
+	if info.Width > 0 && info.Height > 0 {
+		params.ImageWidth = info.Width
+		params.ImageHeight = info.Height
+	}
+	if params.Srcset = r.imageSrcset(info); params.Srcset != "" {
+		params.Sizes = imageSizes(params.Width)
+	}
+}
+
+// smallImageSrc returns the smallest derivative at least minWidth wide, src if there is none
+func (r *Renderer) smallImageSrc(objectId, src string, minWidth int) string {
+	if !r.useDerivatives() {
+		return src
+	}
+	info := r.imageInfo(objectId, src)
+	best := ImageDerivative{Src: src, Width: info.Width}
+	for _, d := range r.Config.ImageDerivatives(info) {
+		if d.Width >= minWidth && (best.Width == 0 || d.Width < best.Width) {
+			best = d
+		}
+	}
+	if s, ok := r.sanitizeUrl(best.Src); ok {
+		return s
+	}
+	return src
+}
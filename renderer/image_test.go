@@ -0,0 +1,192 @@
+package renderer
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/anytype-publish-renderer/utils"
+)
+
+func imageBlock(id, targetId string) *model.Block {
+	return &model.Block{
+		Id: id,
+		Content: &model.BlockContentOfFile{File: &model.BlockContentFile{
+			TargetObjectId: targetId,
+			Type:           model.BlockContentFile_Image,
+			Style:          model.BlockContentFile_Embed,
+		}},
+	}
+}
+
+func imageObject(fields map[string]*types.Value) *pb.SnapshotWithType {
+	return &pb.SnapshotWithType{
+		SbType: model.SmartBlockType_FileObject,
+		Snapshot: &pb.ChangeSnapshot{
+			Data: &model.SmartBlockSnapshotBase{Details: &types.Struct{Fields: fields}},
+		},
+	}
+}
+
+func writeTestPng(t *testing.T, dir, name string, width, height int) {
+	f, err := os.Create(filepath.Join(dir, name))
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height))))
+}
+
+func renderImage(t *testing.T, r *TestRenderer, b *model.Block) string {
+	html, err := utils.TemplToString(r.RenderFile(b))
+	require.NoError(t, err)
+	return html
+}
+
+func TestImageAttributes(t *testing.T) {
+	t.Run("dimensions from file details", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+			filepath.Join("filesObjects", "image"+pbExt): imageObject(map[string]*types.Value{
+				bundle.RelationKeySource.String():         pbtypes.String("files/image.jpg"),
+				bundle.RelationKeyWidthInPixels.String():  pbtypes.Int64(1200),
+				bundle.RelationKeyHeightInPixels.String(): pbtypes.Int64(800),
+			}),
+		}))
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Contains(t, html, `width="1200" height="800"`)
+		assert.Contains(t, html, `loading="lazy" decoding="async"`)
+		assert.NotContains(t, html, "srcset")
+	})
+	t.Run("dimensions from image header", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "files"), 0o755))
+		writeTestPng(t, filepath.Join(dir, "files"), "image.png", 30, 20)
+		r := NewTestRenderer(
+			WithConfig(RenderConfig{PublishFilesPath: dir}),
+			WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+				filepath.Join("filesObjects", "image"+pbExt): imageObject(map[string]*types.Value{
+					bundle.RelationKeySource.String(): pbtypes.String("files/image.png"),
+				}),
+			}),
+		)
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Contains(t, html, `width="30" height="20"`)
+	})
+	t.Run("source outside of files path", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		filesPath := filepath.Join(dir, "package")
+		require.NoError(t, os.Mkdir(filesPath, 0o755))
+		writeTestPng(t, dir, "secret.png", 30, 20)
+		r := NewTestRenderer(
+			WithConfig(RenderConfig{PublishFilesPath: filesPath}),
+			WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+				filepath.Join("filesObjects", "image"+pbExt): imageObject(map[string]*types.Value{
+					bundle.RelationKeySource.String(): pbtypes.String("files/../../secret.png"),
+				}),
+			}),
+		)
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Empty(t, r.localFilePath("files/../../secret.png"))
+		assert.Equal(t, filepath.Join(filesPath, "files", "image.png"), r.localFilePath("files/./image.png"))
+		assert.NotContains(t, html, "width=")
+	})
+	t.Run("unknown dimensions", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+			filepath.Join("filesObjects", "image"+pbExt): imageObject(map[string]*types.Value{
+				bundle.RelationKeySource.String(): pbtypes.String("files/missing.png"),
+			}),
+		}))
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.NotContains(t, html, "width=")
+		assert.Contains(t, html, `loading="lazy"`)
+	})
+	t.Run("srcset from derivatives hook", func(t *testing.T) {
+		// given
+		var hookInfo ImageInfo
+		r := NewTestRenderer(
+			WithConfig(RenderConfig{
+				PublishFilesPath: "https://files.example.com",
+				ImageDerivatives: func(img ImageInfo) []ImageDerivative {
+					hookInfo = img
+					return []ImageDerivative{
+						{Src: "https://files.example.com/files/image-960.jpg", Width: 960},
+						{Src: "https://files.example.com/files/image-480.jpg", Width: 480},
+						// bigger than the original
+						{Src: "https://files.example.com/files/image-2400.jpg", Width: 2400},
+					}
+				},
+			}),
+			WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+				filepath.Join("filesObjects", "image"+pbExt): imageObject(map[string]*types.Value{
+					bundle.RelationKeySource.String():         pbtypes.String("files/image.jpg"),
+					bundle.RelationKeyWidthInPixels.String():  pbtypes.Int64(1200),
+					bundle.RelationKeyHeightInPixels.String(): pbtypes.Int64(800),
+				}),
+			}),
+		)
+
+		// when
+		html := renderImage(t, r, imageBlock("block", "image"))
+
+		// then
+		assert.Equal(t, ImageInfo{ObjectId: "image", Src: "https://files.example.com/files/image.jpg", Width: 1200, Height: 800}, hookInfo)
+		assert.Contains(t, html, `srcset="https://files.example.com/files/image-480.jpg 480w, https://files.example.com/files/image-960.jpg 960w, https://files.example.com/files/image.jpg 1200w"`)
+		assert.Contains(t, html, `sizes="(max-width: 736px) 100vw, 704px"`)
+	})
+}
+
+func TestImageSizes(t *testing.T) {
+	assert.Equal(t, "(max-width: 736px) 100vw, 704px", imageSizes(""))
+	assert.Equal(t, "(max-width: 736px) 100vw, 352px", imageSizes("50%"))
+}
+
+func TestSmallImageSrc(t *testing.T) {
+	// given
+	r := NewTestRenderer(
+		WithConfig(RenderConfig{
+			ImageDerivatives: func(img ImageInfo) []ImageDerivative {
+				return []ImageDerivative{{Src: "/img/icon-320.png", Width: 320}, {Src: "/img/icon-640.png", Width: 640}}
+			},
+		}),
+		WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+			filepath.Join("filesObjects", "icon"+pbExt): imageObject(map[string]*types.Value{
+				bundle.RelationKeySource.String():         pbtypes.String("files/icon.png"),
+				bundle.RelationKeyWidthInPixels.String():  pbtypes.Int64(1000),
+				bundle.RelationKeyHeightInPixels.String(): pbtypes.Int64(1000),
+			}),
+		}),
+	)
+
+	// when, then
+	assert.Equal(t, "/img/icon-320.png", r.smallImageSrc("icon", "/files/icon.png", 96))
+	assert.Equal(t, "/img/icon-640.png", r.smallImageSrc("icon", "/files/icon.png", 400))
+	assert.Equal(t, "/files/icon.png", r.smallImageSrc("icon", "/files/icon.png", 800))
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"go.uber.org/zap"
+)
+
+// DefaultKrokiUrl is used when EmbedsConfig.KrokiUrl is empty
+const DefaultKrokiUrl = "https://kroki.io"
+
+// diagrams bigger than that are left to the iframe
+const krokiMaxSvgSize = 5 << 20
+
+var krokiClient = &http.Client{Timeout: 10 * time.Second}
+
+// newKrokiProcessor makes processor which converts diagram source into svg url of kroki instance at baseUrl
+func newKrokiProcessor(baseUrl string) *BasicEmbedProcessor {
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	var domains []string
+	if parsedUrl, err := url.Parse(baseUrl); err == nil && parsedUrl.Host != "" {
+		domains = append(domains, regexp.QuoteMeta(parsedUrl.Host))
+	}
+
+	return &BasicEmbedProcessor{
+		Id:      model.BlockContentLatex_Kroki,
+		Domains: domains,
+		PrepareFunc: func(b *model.Block, text string) string {
+			// already a diagram url, either of this or other kroki instance
+			if embedWebUrl(strings.TrimSpace(text)) != "" {
+				return text
+			}
+			compressed, err := compressAndEncode(text)
+			if err != nil {
+				return text
+			}
+			typeId := pbtypes.GetString(b.GetFields(), "type")
+			return fmt.Sprintf("%s/%s/svg/%s", baseUrl, url.PathEscape(typeId), compressed)
+		},
+		EmbedOptions: EmbedOptions{
+			AllowEmbedUrl:     true,
+			SkipSanitize:      true,
+			AllowIframeResize: true,
+			UseRootHeight:     true,
+		},
+	}
+}
+
+func (r *Renderer) krokiUrl() string {
+	if r.Config.Embeds.KrokiUrl != "" {
+		return r.Config.Embeds.KrokiUrl
+	}
+	return DefaultKrokiUrl
+}
+
+func fetchKrokiSvg(svgUrl string) ([]byte, error) {
+	resp, err := krokiClient.Get(svgUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kroki responded with %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "image/svg+xml" {
+		return nil, fmt.Errorf("kroki responded with %q instead of svg", mediaType)
+	}
+
+	svg, err := io.ReadAll(io.LimitReader(resp.Body, krokiMaxSvgSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(svg) > krokiMaxSvgSize {
+		return nil, fmt.Errorf("svg is bigger than %d bytes", krokiMaxSvgSize)
+	}
+	return svg, nil
+}
+
+// inlineKroki fetches rendered diagram during rendering, so readers don't request kroki.
+// Svg is inlined as data url image, scripts in it can't run.
+func (r *Renderer) inlineKroki(b *model.Block, svgUrl string) (string, bool) {
+	if !strings.HasPrefix(svgUrl, strings.TrimSuffix(r.krokiUrl(), "/")+"/") {
+		return "", false
+	}
+	svg, err := fetchKrokiSvg(svgUrl)
+	if err != nil {
+		log.Warn("failed to inline kroki diagram, falling back to iframe", zap.String("blockId", b.GetId()), zap.Error(err))
+		return "", false
+	}
+
+	alt := "Diagram"
+	if typeId := pbtypes.GetString(b.GetFields(), "type"); typeId != "" {
+		alt = typeId + " diagram"
+	}
+	src := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg)
+	return fmt.Sprintf(`<img class="krokiDiagram" src="%s" alt="%s" />`, src, html.EscapeString(alt)), true
+}
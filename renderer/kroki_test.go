@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSvg = `<svg xmlns="http://www.w3.org/2000/svg"><rect width="10" height="10"/></svg>`
+
+func krokiBlock(source string) *model.Block {
+	b := embedBlock(model.BlockContentLatex_Kroki, source)
+	b.Fields = &types.Struct{Fields: map[string]*types.Value{"type": pbtypes.String("plantuml")}}
+	return b
+}
+
+// newKrokiStub serves testSvg for plantuml diagrams and counts requests
+func newKrokiStub(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		if !strings.HasPrefix(req.URL.Path, "/plantuml/svg/") {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		_, _ = w.Write([]byte(testSvg))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestKrokiUrl(t *testing.T) {
+	t.Run("public kroki by default", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		params := r.MakeEmbedRenderParams(krokiBlock("A -> B"))
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.True(t, strings.HasPrefix(params.Data.Html, "https://kroki.io/plantuml/svg/"))
+	})
+	t.Run("self-hosted kroki", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{KrokiUrl: "https://kroki.example.com/"}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(krokiBlock("A -> B"))
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.True(t, strings.HasPrefix(params.Data.Html, "https://kroki.example.com/plantuml/svg/"))
+		assert.NotContains(t, params.Data.Html, "kroki.io")
+	})
+}
+
+func TestInlineKroki(t *testing.T) {
+	t.Run("svg is fetched and inlined", func(t *testing.T) {
+		// given
+		server, requests := newKrokiStub(t)
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{KrokiUrl: server.URL, InlineKroki: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(krokiBlock("A -> B"))
+
+		// then
+		assert.False(t, params.IsIframe)
+		assert.Equal(t, int32(1), requests.Load())
+		expected := `<img class="krokiDiagram" src="data:image/svg+xml;base64,` + base64.StdEncoding.EncodeToString([]byte(testSvg)) + `" alt="plantuml diagram" />`
+		assert.Equal(t, expected, params.Content)
+	})
+	t.Run("failed request falls back to iframe", func(t *testing.T) {
+		// given
+		server, _ := newKrokiStub(t)
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{KrokiUrl: server.URL, InlineKroki: true}}))
+		b := krokiBlock("A -> B")
+		b.Fields.Fields["type"] = pbtypes.String("unknown")
+
+		// when
+		params := r.MakeEmbedRenderParams(b)
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.True(t, strings.HasPrefix(params.Data.Html, server.URL+"/unknown/svg/"))
+	})
+	t.Run("diagram from other host is not fetched", func(t *testing.T) {
+		// given
+		server, requests := newKrokiStub(t)
+		r := NewTestRenderer(WithConfig(RenderConfig{Embeds: EmbedsConfig{KrokiUrl: server.URL, InlineKroki: true}}))
+
+		// when
+		params := r.MakeEmbedRenderParams(krokiBlock("https://kroki.io/plantuml/svg/abc"))
+
+		// then
+		assert.True(t, params.IsIframe)
+		assert.Equal(t, int32(0), requests.Load())
+		assert.Equal(t, "https://kroki.io/plantuml/svg/abc", params.Data.Html)
+	})
+}
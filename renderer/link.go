@@ -14,7 +14,8 @@ type LinkRenderParams struct {
 	Id           string
 	SidesClasses string
 	CardClasses  string
-	Url          templ.SafeURL
+	Link         ResolvedLink
+	PreviewId    string
 	Components   []templ.Component
 }
 
@@ -43,7 +44,8 @@ func (r *Renderer) makeLinkBlockParams(b *model.Block) *BlockParams {
 		Id:           b.GetId(),
 		SidesClasses: strings.Join(sidesClasses, " "),
 		CardClasses:  strings.Join(cardClasses, " "),
-		Url:          templ.SafeURL(r.makeAnytypeLink(targetDetails, targetObjectId)),
+		Link:         r.resolveLink(LinkKindBlock, targetDetails, targetObjectId),
+		PreviewId:    r.previewId(targetObjectId),
 		Components:   linkComponents,
 	}
 	blockParams.Content = LinkTemplate(lp)
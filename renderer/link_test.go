@@ -297,6 +297,7 @@ func TestMakeLinkRenderParams(t *testing.T) {
 		actual := r.makeLinkBlockParams(block)
 		pathAssertions := []pathAssertion{
 			{"a.linkCard.isHuman.c2 > attrs[href]", "anytype://object?objectId=test-id&spaceId=spaceId"},
+			{"a.linkCard.isHuman.c2 > attrs[rel]", "noopener noreferrer nofollow"},
 			{"a.linkCard.isHuman.c2 > div.sides > div.side.left > div.cardName > div.name > Content", "Test"},
 			{"a.linkCard.isHuman.c2 > div.sides > div.side.left > div.relationItem.cardDescription > div.description > Content", "description"},
 		}
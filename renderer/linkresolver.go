@@ -0,0 +1,125 @@
+package renderer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/localstore/addr"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/gogo/protobuf/types"
+	"go.uber.org/zap"
+)
+
+const linkTemplate = "anytype://object?objectId=%s&spaceId=%s"
+
+// LinkKind is a place of the page where an object is referenced
+type LinkKind int
+
+const (
+	LinkKindBlock LinkKind = iota
+	LinkKindMention
+	LinkKindObjectMark
+	// object relation values, featured relations too
+	LinkKindRelation
+)
+
+// LinkTarget is an object referenced by the page
+type LinkTarget struct {
+	ObjectId string
+	Details  *types.Struct
+	Kind     LinkKind
+	// snapshot of the object is a part of the package, date objects are not
+	InPackage bool
+	// url of the package file, only for file objects
+	FileUrl string
+}
+
+// ResolvedLink is rendered as href, target and rel attributes, which are omitted when empty.
+// Objects without url are rendered as plain text or cards without href.
+type ResolvedLink struct {
+	Url    string
+	Target string
+	Rel    string
+}
+
+// LinkResolver decides where references to other objects lead
+type LinkResolver interface {
+	ResolveLink(target LinkTarget) ResolvedLink
+}
+
+// AnytypeLinkResolver opens objects in anytype app and files from the package.
+// It is used when RenderConfig.LinkResolver is nil.
+type AnytypeLinkResolver struct{}
+
+func (AnytypeLinkResolver) ResolveLink(target LinkTarget) ResolvedLink {
+	link := ResolvedLink{Rel: linkRel}
+	if isFileTarget(target.Details) {
+		link.Url = target.FileUrl
+	} else {
+		spaceId := getRelationField(target.Details, bundle.RelationKeySpaceId, relationToString)
+		link.Url = fmt.Sprintf(linkTemplate, target.ObjectId, spaceId)
+	}
+	// text links are opened in a new tab
+	if target.Kind == LinkKindMention || target.Kind == LinkKindObjectMark {
+		link.Target = "_blank"
+	}
+	return link
+}
+
+// UrlTemplateLinkResolver links objects of the package to pages of the host,
+// Template may contain {objectId} and {spaceId} placeholders, e.g. https://example.com/{objectId}.
+// Files and objects outside of the package are resolved by AnytypeLinkResolver.
+type UrlTemplateLinkResolver struct {
+	Template string
+}
+
+func (t UrlTemplateLinkResolver) ResolveLink(target LinkTarget) ResolvedLink {
+	if !target.InPackage || isFileTarget(target.Details) {
+		return AnytypeLinkResolver{}.ResolveLink(target)
+	}
+	spaceId := getRelationField(target.Details, bundle.RelationKeySpaceId, relationToString)
+	return ResolvedLink{
+		Url: strings.NewReplacer(
+			"{objectId}", url.PathEscape(target.ObjectId),
+			"{spaceId}", url.PathEscape(spaceId),
+		).Replace(t.Template),
+	}
+}
+
+func isFileTarget(details *types.Struct) bool {
+	layout := getRelationField(details, bundle.RelationKeyLayout, relationToObjectTypeLayout)
+	switch layout {
+	case model.ObjectType_file, model.ObjectType_image, model.ObjectType_pdf, model.ObjectType_audio, model.ObjectType_video:
+		return true
+	}
+	return false
+}
+
+func (r *Renderer) linkResolver() LinkResolver {
+	if r.Config.LinkResolver != nil {
+		return r.Config.LinkResolver
+	}
+	return AnytypeLinkResolver{}
+}
+
+// resolveLink returns link to the referenced object, its url is empty if it is not allowed
+func (r *Renderer) resolveLink(kind LinkKind, details *types.Struct, objectId string) ResolvedLink {
+	target := LinkTarget{
+		ObjectId:  objectId,
+		Details:   details,
+		Kind:      kind,
+		InPackage: len(details.GetFields()) != 0 && !strings.HasPrefix(objectId, addr.DatePrefix),
+	}
+	if isFileTarget(details) {
+		src, err := r.getFileUrl(objectId)
+		if err != nil {
+			log.Error("failed to get file url", zap.Error(err))
+		}
+		target.FileUrl = src
+	}
+	link := r.linkResolver().ResolveLink(target)
+	link.Url, _ = r.sanitizeUrl(link.Url)
+	return link
+}
@@ -0,0 +1,116 @@
+package renderer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/anytype-publish-renderer/utils"
+)
+
+type recordingLinkResolver struct {
+	targets []LinkTarget
+}
+
+func (rl *recordingLinkResolver) ResolveLink(target LinkTarget) ResolvedLink {
+	rl.targets = append(rl.targets, target)
+	return ResolvedLink{Url: "https://example.com/" + target.ObjectId}
+}
+
+func pageDetails(id string) *types.Struct {
+	return &types.Struct{Fields: map[string]*types.Value{
+		bundle.RelationKeyId.String():      pbtypes.String(id),
+		bundle.RelationKeyName.String():    pbtypes.String("Page"),
+		bundle.RelationKeySpaceId.String(): pbtypes.String("spaceId"),
+	}}
+}
+
+func fileDetails() *types.Struct {
+	return &types.Struct{Fields: map[string]*types.Value{
+		bundle.RelationKeyLayout.String(): pbtypes.Int64(int64(model.ObjectType_file)),
+	}}
+}
+
+func TestAnytypeLinkResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   LinkTarget
+		expected ResolvedLink
+	}{
+		{
+			name:     "link block",
+			target:   LinkTarget{ObjectId: "page", Details: pageDetails("page"), Kind: LinkKindBlock, InPackage: true},
+			expected: ResolvedLink{Url: "anytype://object?objectId=page&spaceId=spaceId", Rel: linkRel},
+		},
+		{
+			name:     "mention",
+			target:   LinkTarget{ObjectId: "page", Details: pageDetails("page"), Kind: LinkKindMention, InPackage: true},
+			expected: ResolvedLink{Url: "anytype://object?objectId=page&spaceId=spaceId", Target: "_blank", Rel: linkRel},
+		},
+		{
+			name:     "file",
+			target:   LinkTarget{ObjectId: "file", Details: fileDetails(), Kind: LinkKindRelation, InPackage: true, FileUrl: "/files/file.txt"},
+			expected: ResolvedLink{Url: "/files/file.txt", Rel: linkRel},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnytypeLinkResolver{}.ResolveLink(tt.target))
+		})
+	}
+}
+
+func TestUrlTemplateLinkResolver(t *testing.T) {
+	// given
+	resolver := UrlTemplateLinkResolver{Template: "https://example.com/{spaceId}/{objectId}"}
+
+	// when
+	inPackage := resolver.ResolveLink(LinkTarget{ObjectId: "page", Details: pageDetails("page"), Kind: LinkKindMention, InPackage: true})
+	outside := resolver.ResolveLink(LinkTarget{ObjectId: "_date_2025-01-01", Details: pageDetails("_date_2025-01-01"), Kind: LinkKindBlock})
+	file := resolver.ResolveLink(LinkTarget{ObjectId: "file", Details: fileDetails(), InPackage: true, FileUrl: "/files/file.txt"})
+
+	// then
+	assert.Equal(t, ResolvedLink{Url: "https://example.com/spaceId/page"}, inPackage)
+	assert.Equal(t, "anytype://object?objectId=_date_2025-01-01&spaceId=spaceId", outside.Url)
+	assert.Equal(t, "/files/file.txt", file.Url)
+}
+
+func TestLinkResolverConfig(t *testing.T) {
+	// given
+	resolver := &recordingLinkResolver{}
+	r := NewTestRenderer(
+		WithConfig(RenderConfig{LinkResolver: resolver}),
+		WithCachedPbFiles(map[string]*pb.SnapshotWithType{
+			filepath.Join("objects", "page"+pbExt): {
+				SbType:   model.SmartBlockType_Page,
+				Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{Details: pageDetails("page")}},
+			},
+		}),
+	)
+
+	// when
+	mark := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Object, "page"))
+	markHtml, err := utils.TemplToString(mark.Content)
+	require.NoError(t, err)
+	mention := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Mention, "page"))
+	mentionHtml, err := utils.TemplToString(mention.Content)
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, resolver.targets, 2)
+	assert.Equal(t, LinkKindObjectMark, resolver.targets[0].Kind)
+	assert.Equal(t, LinkKindMention, resolver.targets[1].Kind)
+	assert.True(t, resolver.targets[0].InPackage)
+	assert.Equal(t, "page", resolver.targets[0].ObjectId)
+	assert.Contains(t, markHtml, `<a href="https://example.com/page" class="markuplink">`)
+	assert.Contains(t, mentionHtml, `href="https://example.com/page"`)
+	assert.NotContains(t, mentionHtml, "target=")
+	assert.NotContains(t, mentionHtml, "rel=")
+}
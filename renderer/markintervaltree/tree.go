@@ -1,6 +1,9 @@
 package markintervaltree
 
 import (
+	"iter"
+	"slices"
+
 	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
 )
 
@@ -11,20 +14,50 @@ type MarkIntervalTreeNode struct {
 	Right       *MarkIntervalTreeNode
 }
 
+// New builds a balanced tree from marks.
+//
+// Marks are ordered by Range.From (keeping the original order for equal
+// starts) and the median of every run becomes the subtree root, so the tree
+// depth is ceil(log2(n+1)) regardless of how the marks were sorted.
 func New(marks []*model.BlockContentTextMark) *MarkIntervalTreeNode {
-	root := &MarkIntervalTreeNode{
-		Mark:        marks[0],
-		MaxUpperVal: marks[0].Range.To,
+	if len(marks) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(marks)
+	slices.SortStableFunc(sorted, func(a, b *model.BlockContentTextMark) int {
+		return int(a.Range.From) - int(b.Range.From)
+	})
+
+	return build(sorted)
+}
+
+func build(marks []*model.BlockContentTextMark) *MarkIntervalTreeNode {
+	if len(marks) == 0 {
+		return nil
 	}
 
-	for i := 1; i < len(marks); i++ {
-		root.Insert(marks[i])
+	// for even runs take the left median so equal starts stay in-order
+	mid := (len(marks) - 1) / 2
+	node := &MarkIntervalTreeNode{
+		Mark:        marks[mid],
+		MaxUpperVal: marks[mid].Range.To,
+		Left:        build(marks[:mid]),
+		Right:       build(marks[mid+1:]),
 	}
 
-	return root
+	if node.Left != nil && node.Left.MaxUpperVal > node.MaxUpperVal {
+		node.MaxUpperVal = node.Left.MaxUpperVal
+	}
+	if node.Right != nil && node.Right.MaxUpperVal > node.MaxUpperVal {
+		node.MaxUpperVal = node.Right.MaxUpperVal
+	}
 
+	return node
 }
 
+// Insert adds a single mark without rebalancing.
+// Prefer New when all marks are known upfront.
 func (r *MarkIntervalTreeNode) Insert(m *model.BlockContentTextMark) {
 	node := r
 	for node != nil {
@@ -63,24 +96,45 @@ func rangeOverlap(a, b *model.Range) bool {
 		b.To && a.To > b.From) || (a.From == b.From && a.To == b.To)
 }
 
+// startsAfter reports whether a mark starting at `from` and every mark
+// starting later can not overlap i
+func startsAfter(from int32, i *model.Range) bool {
+	if from == i.To {
+		// only an identical empty range may still match
+		return i.From != i.To
+	}
+	return from > i.To
+}
+
 func (r *MarkIntervalTreeNode) SearchOverlaps(i *model.Range) []*model.BlockContentTextMark {
 	marksToApply := make([]*model.BlockContentTextMark, 0)
-	searchOverlaps(r, i, &marksToApply)
+	for m := range r.Overlaps(i) {
+		marksToApply = append(marksToApply, m)
+	}
 	return marksToApply
 }
 
-func searchOverlaps(n *MarkIntervalTreeNode, i *model.Range, result *[]*model.BlockContentTextMark) {
+// Overlaps iterates over marks overlapping i, ordered by Range.From
+func (r *MarkIntervalTreeNode) Overlaps(i *model.Range) iter.Seq[*model.BlockContentTextMark] {
+	return func(yield func(*model.BlockContentTextMark) bool) {
+		searchOverlaps(r, i, yield)
+	}
+}
+
+func searchOverlaps(n *MarkIntervalTreeNode, i *model.Range, yield func(*model.BlockContentTextMark) bool) bool {
 	if n == nil || n.MaxUpperVal < i.From {
-		return
+		return true
+	}
+	if !searchOverlaps(n.Left, i, yield) {
+		return false
 	}
-	searchOverlaps(n.Left, i, result)
 
-	if rangeOverlap(n.Mark.Range, i) {
-		*result = append(*result, n.Mark)
+	if startsAfter(n.Mark.Range.From, i) {
+		return true
 	}
-	if i.From < n.Mark.Range.From {
-		return
+	if rangeOverlap(n.Mark.Range, i) && !yield(n.Mark) {
+		return false
 	}
 
-	searchOverlaps(n.Right, i, result)
+	return searchOverlaps(n.Right, i, yield)
 }
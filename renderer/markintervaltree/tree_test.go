@@ -1,6 +1,8 @@
 package markintervaltree
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,3 +124,141 @@ func TestMarkIntervalTree(t *testing.T) {
 	})
 
 }
+
+func TestMarkIntervalTreeBalance(t *testing.T) {
+	t.Run("sorted marks", func(t *testing.T) {
+		marks := sortedMarks(1000)
+
+		root := New(marks)
+
+		maxDepth := int(math.Ceil(math.Log2(float64(len(marks) + 1))))
+		assert.LessOrEqual(t, depth(root), maxDepth)
+	})
+
+	t.Run("nested marks", func(t *testing.T) {
+		marks := nestedMarks(1000)
+
+		root := New(marks)
+
+		maxDepth := int(math.Ceil(math.Log2(float64(len(marks) + 1))))
+		assert.LessOrEqual(t, depth(root), maxDepth)
+	})
+
+	t.Run("equal starts keep input order", func(t *testing.T) {
+		marks := []*model.BlockContentTextMark{
+			{Range: &model.Range{From: 0, To: 4}, Type: model.BlockContentTextMark_Bold},
+			{Range: &model.Range{From: 0, To: 4}, Type: model.BlockContentTextMark_Italic},
+			{Range: &model.Range{From: 0, To: 4}, Type: model.BlockContentTextMark_Link},
+		}
+
+		results := New(marks).SearchOverlaps(&model.Range{From: 1, To: 2})
+
+		assert.Equal(t, marks, results)
+	})
+
+	t.Run("empty marks", func(t *testing.T) {
+		root := New(nil)
+
+		results := root.SearchOverlaps(&model.Range{From: 0, To: 1})
+
+		assert.Empty(t, results)
+	})
+}
+
+func TestMarkIntervalTreeOverlaps(t *testing.T) {
+	marks := sortedMarks(100)
+	root := New(marks)
+
+	t.Run("query spanning many marks", func(t *testing.T) {
+		var results []*model.BlockContentTextMark
+		for m := range root.Overlaps(&model.Range{From: 15, To: 45}) {
+			results = append(results, m)
+		}
+
+		assert.Equal(t, marks[15:45], results)
+	})
+
+	t.Run("early break", func(t *testing.T) {
+		var results []*model.BlockContentTextMark
+		for m := range root.Overlaps(&model.Range{From: 15, To: 45}) {
+			results = append(results, m)
+			if len(results) == 3 {
+				break
+			}
+		}
+
+		assert.Equal(t, marks[15:18], results)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		empty := &model.BlockContentTextMark{Range: &model.Range{From: 10, To: 10}}
+		root := New(append(sortedMarks(20), empty))
+
+		results := root.SearchOverlaps(&model.Range{From: 10, To: 10})
+
+		assert.Equal(t, []*model.BlockContentTextMark{empty}, results)
+	})
+}
+
+// marks [0,1) [1,2) ... in ascending order: degenerates an insertion-order BST into a list
+func sortedMarks(n int) []*model.BlockContentTextMark {
+	marks := make([]*model.BlockContentTextMark, n)
+	for i := range n {
+		marks[i] = &model.BlockContentTextMark{
+			Range: &model.Range{From: int32(i), To: int32(i + 1)},
+		}
+	}
+	return marks
+}
+
+// marks [0,2n) [1,2n-1) ... each one inside the previous one
+func nestedMarks(n int) []*model.BlockContentTextMark {
+	marks := make([]*model.BlockContentTextMark, n)
+	for i := range n {
+		marks[i] = &model.BlockContentTextMark{
+			Range: &model.Range{From: int32(i), To: int32(2*n - i)},
+		}
+	}
+	return marks
+}
+
+func depth(n *MarkIntervalTreeNode) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(depth(n.Left), depth(n.Right))
+}
+
+func BenchmarkNew(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 10000} {
+		marks := sortedMarks(n)
+		b.Run(fmt.Sprintf("sorted-%d", n), func(b *testing.B) {
+			for range b.N {
+				New(marks)
+			}
+		})
+	}
+}
+
+// SearchOverlaps is called once per segment between mark borders,
+// so the time per query should grow with log(n), not n
+func BenchmarkSearchOverlaps(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 10000} {
+		for _, set := range []struct {
+			name  string
+			marks []*model.BlockContentTextMark
+		}{
+			{"sorted", sortedMarks(n)},
+			{"nested", nestedMarks(n)},
+		} {
+			root := New(set.marks)
+			b.Run(fmt.Sprintf("%s-%d", set.name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := range b.N {
+					from := int32(i % n)
+					root.SearchOverlaps(&model.Range{From: from, To: from + 1})
+				}
+			})
+		}
+	}
+}
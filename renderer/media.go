@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+)
+
+// cover art is shown at 48px
+const audioPictureWidth = 96
+
+// AudioMeta is shown above audio player
+type AudioMeta struct {
+	Title       string
+	Artist      string
+	Album       string
+	TrackNumber int
+	// cover art
+	Picture string
+}
+
+// imageRelationUrl returns url of image file which relation of details points to
+func (r *Renderer) imageRelationUrl(objectId string, key string, minWidth int) string {
+	details := r.findTargetDetails(objectId)
+	imageId := pbtypes.GetString(details, key)
+	if imageId == "" {
+		return ""
+	}
+	src, err := r.getFileUrl(imageId)
+	if err != nil {
+		return ""
+	}
+	if src, ok := r.sanitizeUrl(src); ok {
+		return r.smallImageSrc(imageId, src, minWidth)
+	}
+	return ""
+}
+
+// mediaPicture returns thumbnail of audio or video file, it is either its picture or icon
+func (r *Renderer) mediaPicture(objectId string, minWidth int) string {
+	if src := r.imageRelationUrl(objectId, bundle.RelationKeyPicture.String(), minWidth); src != "" {
+		return src
+	}
+	return r.imageRelationUrl(objectId, bundle.RelationKeyIconImage.String(), minWidth)
+}
+
+func (r *Renderer) videoPoster(objectId string) string {
+	return r.mediaPicture(objectId, contentWidth)
+}
+
+// audioMeta reads track details of audio file, duration is shown by js from the file itself
+func (r *Renderer) audioMeta(objectId string) *AudioMeta {
+	details := r.findTargetDetails(objectId)
+	meta := &AudioMeta{
+		Title:       getRelationField(details, bundle.RelationKeyName, relationToString),
+		Artist:      getRelationField(details, bundle.RelationKeyArtist, relationToString),
+		Album:       getRelationField(details, bundle.RelationKeyAudioAlbum, relationToString),
+		TrackNumber: int(getRelationField(details, bundle.RelationKeyAudioAlbumTrackNumber, relationToInt64)),
+	}
+	if meta.Artist == "" {
+		meta.Artist = getRelationField(details, bundle.RelationKeyMediaArtistName, relationToString)
+	}
+	if meta.Title == "" && meta.Artist == "" && meta.Album == "" {
+		return nil
+	}
+	meta.Picture = r.mediaPicture(objectId, audioPictureWidth)
+	return meta
+}
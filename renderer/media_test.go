@@ -0,0 +1,101 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func mediaObject(t *testing.T, id string, fields map[string]*types.Value) Option {
+	return WithLinkedSnapshot(t, "filesObjects/"+id+pbExt, &pb.SnapshotWithType{
+		SbType: model.SmartBlockType_FileObject,
+		Snapshot: &pb.ChangeSnapshot{
+			Data: &model.SmartBlockSnapshotBase{Details: &types.Struct{Fields: fields}},
+		},
+	})
+}
+
+func TestVideoPoster(t *testing.T) {
+	t.Run("poster from picture", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(
+			mediaObject(t, "video", map[string]*types.Value{
+				bundle.RelationKeySource.String():  pbtypes.String("files/talk.mp4"),
+				bundle.RelationKeyPicture.String(): pbtypes.String("thumb"),
+			}),
+			fileObject(t, "thumb", "files/talk.jpg", "", ""),
+		)
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "video", model.BlockContentFile_Video))
+
+		// then
+		assert.Contains(t, html, `<video controls src="/files/talk.mp4" poster="/files/talk.jpg" preload="none">`)
+	})
+	t.Run("no poster", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "video", "files/talk.mp4", "", ""))
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "video", model.BlockContentFile_Video))
+
+		// then
+		assert.NotContains(t, html, "poster")
+	})
+}
+
+func TestAudioMeta(t *testing.T) {
+	t.Run("track details", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(
+			mediaObject(t, "audio", map[string]*types.Value{
+				bundle.RelationKeySource.String():                pbtypes.String("files/song.mp3"),
+				bundle.RelationKeyName.String():                  pbtypes.String("Song"),
+				bundle.RelationKeyArtist.String():                pbtypes.String("Artist"),
+				bundle.RelationKeyAudioAlbum.String():            pbtypes.String("Album"),
+				bundle.RelationKeyAudioAlbumTrackNumber.String(): pbtypes.Int64(3),
+				bundle.RelationKeyPicture.String():               pbtypes.String("art"),
+			}),
+			fileObject(t, "art", "files/art.jpg", "", ""),
+		)
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "audio", model.BlockContentFile_Audio))
+
+		// then
+		assert.Contains(t, html, `<img class="audioPicture" src="/files/art.jpg" alt="" loading="lazy">`)
+		assert.Contains(t, html, `<div class="audioTitle"><span class="trackNumber">3.</span> Song</div>`)
+		assert.Contains(t, html, `<div class="audioDetails"><span class="artist">Artist</span> <span class="album">Album</span></div>`)
+		assert.Contains(t, html, `<span class="duration"></span>`)
+	})
+	t.Run("artist of media from the web", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(mediaObject(t, "audio", map[string]*types.Value{
+			bundle.RelationKeySource.String():          pbtypes.String("files/song.mp3"),
+			bundle.RelationKeyMediaArtistName.String(): pbtypes.String("Someone"),
+		}))
+
+		// when
+		meta := r.audioMeta("audio")
+
+		// then
+		assert.Equal(t, &AudioMeta{Artist: "Someone"}, meta)
+	})
+	t.Run("no details", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(mediaObject(t, "audio", map[string]*types.Value{
+			bundle.RelationKeySource.String(): pbtypes.String("files/song.mp3"),
+		}))
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "audio", model.BlockContentFile_Audio))
+
+		// then
+		assert.NotContains(t, html, "audioMeta")
+	})
+}
@@ -59,7 +59,7 @@ func (r *Renderer) getOgImageUrl() string {
 
 func (r *Renderer) hasPageIcon() bool {
 	details := r.Sp.Snapshot.Data.GetDetails()
-	iconEmoji := getRelationField(details, bundle.RelationKeyIconEmoji, r.relationToEmojiUrl)
+	iconEmoji := getRelationField(details, bundle.RelationKeyIconEmoji, relationToEmoji)
 	iconImage := getRelationField(details, bundle.RelationKeyIconImage, r.relationToFileUrl)
 
 	if isTodoLayout(r.ResolvedLayout) {
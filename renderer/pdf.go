@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+)
+
+// only head and tail of the file are scanned, root of the page tree is near
+// the start of linearized files and near the end of incrementally updated ones
+const pdfScanSize = 64 << 10
+
+var (
+	pdfObjectRegexp = regexp.MustCompile(`\d+\s+\d+\s+obj\b`)
+	pdfPagesRegexp  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfParentRegexp = regexp.MustCompile(`/Parent\s`)
+	pdfCountRegexp  = regexp.MustCompile(`/Count\s+(\d+)`)
+)
+
+// readPdfPageCount reads page count from root of the page tree. It is not found
+// in compressed object streams or in the middle of big files, then 0 is returned.
+func readPdfPageCount(r io.ReaderAt, size int64) int {
+	head, err := readPdfChunk(r, 0, min(size, pdfScanSize))
+	if err != nil {
+		return 0
+	}
+	count := pdfPageTreeCount(head)
+	if size > pdfScanSize {
+		tailSize := min(size-pdfScanSize, pdfScanSize)
+		tail, err := readPdfChunk(r, size-tailSize, tailSize)
+		if err != nil {
+			return count
+		}
+		// the tail is appended later, so its objects are newer
+		if n := pdfPageTreeCount(tail); n != 0 {
+			count = n
+		}
+	}
+	return count
+}
+
+func readPdfChunk(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	data := make([]byte, size)
+	n, err := r.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// pdfPageTreeCount returns /Count of the last page tree root in data, 0 if there is none
+func pdfPageTreeCount(data []byte) int {
+	count := 0
+	for _, object := range bytes.Split(data, []byte("endobj")) {
+		headers := pdfObjectRegexp.FindAllIndex(object, -1)
+		if headers == nil {
+			continue
+		}
+		object = object[headers[len(headers)-1][1]:]
+		// stream data is binary, page tree is never a stream
+		if bytes.Contains(object, []byte("stream")) || !pdfPagesRegexp.Match(object) || pdfParentRegexp.Match(object) {
+			continue
+		}
+		// incremental updates append newer versions of the object, so the last one wins
+		if countMatch := pdfCountRegexp.FindSubmatch(object); countMatch != nil {
+			if n, err := strconv.Atoi(string(countMatch[1])); err == nil {
+				count = n
+			}
+		}
+	}
+	return count
+}
+
+// pdfPageCount returns page count of pdf file of the package, 0 if unknown
+func (r *Renderer) pdfPageCount(objectId string) int {
+	details := r.findTargetDetails(objectId)
+	path := r.localFilePath(pbtypes.GetString(details, bundle.RelationKeySource.String()))
+	if path == "" {
+		return 0
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	return readPdfPageCount(f, info.Size())
+}
+
+func pdfPagesText(count int) string {
+	if count == 1 {
+		return "1 page"
+	}
+	return strconv.Itoa(count) + " pages"
+}
@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPdf = `%PDF-1.4
+1 0 obj
+<</Type/Catalog/Pages 2 0 R>>
+endobj
+2 0 obj
+<</Type/Pages/Kids[3 0 R 4 0 R]/Count 3>>
+endobj
+3 0 obj
+<</Type/Pages/Parent 2 0 R/Kids[5 0 R 6 0 R]/Count 2>>
+endobj
+7 0 obj
+<</Count 5/First 8 0 R>>
+endobj
+`
+
+// pdfPadding is a content stream object of given size
+func pdfPadding(size int) string {
+	return "10 0 obj\n<</Length 0>>stream\n" + strings.Repeat("q Q ", size/4) + "\nendstream\nendobj\n"
+}
+
+func TestReadPdfPageCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		pdf      string
+		expected int
+	}{
+		{"root of page tree", testPdf, 3},
+		{"incremental update", testPdf + "2 0 obj\n<</Type /Pages /Kids[3 0 R 4 0 R 9 0 R] /Count 4>>\nendobj\n", 4},
+		{"compressed page tree", "%PDF-1.5\n1 0 obj\n<</Type/ObjStm/N 3>>stream\n...\nendstream\nendobj\n", 0},
+		{"xref stream", testPdf + "9 0 obj\n<</Type/XRef/Size 10/W[1 2 1]/Length 8>>stream\n/Type/Pages /Count 9\nendstream\nendobj\n", 3},
+		{"not a pdf", "hello", 0},
+		{"page tree in the tail of big file", testPdf + pdfPadding(2*pdfScanSize) + "2 0 obj\n<</Type/Pages/Kids[3 0 R]/Count 7>>\nendobj\n", 7},
+		{"page tree in the middle of big file", "%PDF-1.4\n" + pdfPadding(pdfScanSize) + testPdf + pdfPadding(pdfScanSize), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, readPdfPageCount(strings.NewReader(tt.pdf), int64(len(tt.pdf))))
+		})
+	}
+}
+
+func TestPdfFallback(t *testing.T) {
+	t.Run("fallback with page count", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "files"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "files", "doc.pdf"), []byte(testPdf), 0o644))
+		r := NewTestRenderer(
+			WithConfig(RenderConfig{PublishFilesPath: dir}),
+			fileObject(t, "pdf", "files/doc.pdf", "Report", ""),
+		)
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "pdf", model.BlockContentFile_PDF))
+
+		// then
+		src := dir + "/files/doc.pdf"
+		assert.Contains(t, html, `<span class="name">Report</span>`)
+		assert.Contains(t, html, `<span class="pages">3 pages</span>`)
+		assert.Contains(t, html, `<a class="button black c28" href="`+src+`" target="_blank" rel="noopener noreferrer nofollow">Open</a>`)
+		assert.Contains(t, html, `<a class="button blank c28" href="`+src+`" download="Report">Download</a>`)
+	})
+	t.Run("page count is unknown", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(fileObject(t, "pdf", "files/doc.pdf", "Report", ""))
+
+		// when
+		html := renderImage(t, r, mediaBlock("block", "pdf", model.BlockContentFile_PDF))
+
+		// then
+		assert.Contains(t, html, `class="pdfFallback"`)
+		assert.NotContains(t, html, `class="pages"`)
+	})
+}
@@ -0,0 +1,106 @@
+package renderer
+
+import (
+	"github.com/a-h/templ"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+)
+
+const previewIconSize = 48
+
+// ObjectPreview is shown as a card on hover or focus of mentions and links
+type ObjectPreview struct {
+	Id          string
+	Name        string
+	TypeName    string
+	Description string
+	Icon        templ.Component
+	Cover       templ.Component
+}
+
+// referencedObjects returns ids of objects mentioned or linked on the page, in page order
+func (r *Renderer) referencedObjects() []string {
+	var ids []string
+	for _, b := range r.traverseBlocks(r.BlocksById, r.Root.GetId(), false) {
+		if link := b.GetLink(); link != nil {
+			ids = append(ids, link.GetTargetBlockId())
+		}
+		for _, mark := range b.GetText().GetMarks().GetMarks() {
+			switch mark.GetType() {
+			case model.BlockContentTextMark_Mention, model.BlockContentTextMark_Object:
+				ids = append(ids, mark.GetParam())
+			}
+		}
+	}
+	return ids
+}
+
+// hydratePreviews collects preview data of referenced objects which are in the package
+func (r *Renderer) hydratePreviews() {
+	r.Previews = make(map[string]*ObjectPreview)
+	r.PreviewIds = nil
+
+	for _, id := range r.referencedObjects() {
+		if id == "" || r.Previews[id] != nil {
+			continue
+		}
+		details := r.findTargetDetails(id)
+		if details == nil || len(details.Fields) == 0 || getRelationField(details, bundle.RelationKeyIsDeleted, relationToBool) {
+			continue
+		}
+		r.Previews[id] = r.makeObjectPreview(id, details)
+		r.PreviewIds = append(r.PreviewIds, id)
+	}
+}
+
+func (r *Renderer) makeObjectPreview(id string, details *types.Struct) *ObjectPreview {
+	preview := &ObjectPreview{
+		Id:          id,
+		Name:        getNameValue(details, bundle.RelationKeyName.String(), defaultName),
+		Description: getNameValue(details, bundle.RelationKeyDescription.String(), ""),
+	}
+	if preview.Description == "" {
+		preview.Description = getNameValue(details, bundle.RelationKeySnippet.String(), "")
+	}
+
+	objectType := getRelationField(details, bundle.RelationKeyType, relationToString)
+	if typeDetails := r.findTargetDetails(objectType); typeDetails != nil {
+		preview.TypeName = getRelationField(typeDetails, bundle.RelationKeyName, relationToString)
+	}
+
+	params := r.MakeRenderIconObjectParams(details, &IconObjectProps{
+		Size:     previewIconSize,
+		IconSize: previewIconSize,
+	})
+	if params.Src != "" || params.SvgSrc != "" || params.Emoji != "" {
+		preview.Icon = IconObjectTemplate(r, params)
+	}
+
+	if pbtypes.GetString(details, "coverId") != "" {
+		if coverParams, err := r.getCoverParams(details, false, false, false); err == nil {
+			preview.Cover = coverParams.CoverTemplate
+		}
+	}
+	return preview
+}
+
+// previewId returns objectId if the object has a preview card, empty string otherwise
+func (r *Renderer) previewId(objectId string) string {
+	if r.Previews == nil {
+		r.hydratePreviews()
+	}
+	if r.Previews[objectId] == nil {
+		return ""
+	}
+	return objectId
+}
+
+func (r *Renderer) RenderPreviews() templ.Component {
+	previews := make([]*ObjectPreview, 0, len(r.PreviewIds))
+	for _, id := range r.PreviewIds {
+		previews = append(previews, r.Previews[id])
+	}
+	return ObjectPreviewsTemplate(previews)
+}
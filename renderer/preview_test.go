@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pkg/lib/bundle"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/anyproto/anytype-heart/util/pbtypes"
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anyproto/anytype-publish-renderer/utils/tests/htmltag"
+)
+
+func previewSnapshots() map[string]*pb.SnapshotWithType {
+	return map[string]*pb.SnapshotWithType{
+		filepath.Join("objects", "target-id.pb"): {
+			SbType: model.SmartBlockType_Page,
+			Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+				Details: &types.Struct{Fields: map[string]*types.Value{
+					bundle.RelationKeyId.String():        pbtypes.String("target-id"),
+					bundle.RelationKeyName.String():      pbtypes.String("Target"),
+					bundle.RelationKeySnippet.String():   pbtypes.String("Snippet text"),
+					bundle.RelationKeyType.String():      pbtypes.String("type-id"),
+					bundle.RelationKeyIconEmoji.String(): pbtypes.String("📘"),
+					bundle.RelationKeySpaceId.String():   pbtypes.String("spaceId"),
+				}},
+			}},
+		},
+		filepath.Join("objects", "type-id.pb"): {
+			SbType: model.SmartBlockType_STType,
+			Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+				Details: &types.Struct{Fields: map[string]*types.Value{
+					bundle.RelationKeyId.String():   pbtypes.String("type-id"),
+					bundle.RelationKeyName.String(): pbtypes.String("Book"),
+				}},
+			}},
+		},
+		filepath.Join("objects", "deleted-id.pb"): {
+			SbType: model.SmartBlockType_Page,
+			Snapshot: &pb.ChangeSnapshot{Data: &model.SmartBlockSnapshotBase{
+				Details: &types.Struct{Fields: map[string]*types.Value{
+					bundle.RelationKeyId.String():        pbtypes.String("deleted-id"),
+					bundle.RelationKeyIsDeleted.String(): pbtypes.Bool(true),
+				}},
+			}},
+		},
+	}
+}
+
+func linkBlock(id, targetId string) *model.Block {
+	return &model.Block{Id: id, Content: &model.BlockContentOfLink{Link: &model.BlockContentLink{TargetBlockId: targetId}}}
+}
+
+func TestHydratePreviews(t *testing.T) {
+	// given
+	mention := textBlockWithMark(model.BlockContentTextMark_Mention, "target-id")
+	mention.Id = "mention"
+	r := newPageRenderer(
+		mention,
+		linkBlock("link", "target-id"),
+		linkBlock("deleted", "deleted-id"),
+		linkBlock("missing", "missing-id"),
+	)
+	r.CachedPbFiles = previewSnapshots()
+
+	// when
+	r.hydratePreviews()
+
+	// then
+	assert.Equal(t, []string{"target-id"}, r.PreviewIds)
+	preview := r.Previews["target-id"]
+	assert.Equal(t, "Target", preview.Name)
+	assert.Equal(t, "Book", preview.TypeName)
+	assert.Equal(t, "Snippet text", preview.Description)
+	assert.NotNil(t, preview.Icon)
+	assert.Nil(t, preview.Cover)
+	assert.Equal(t, "target-id", r.previewId("target-id"))
+	assert.Equal(t, "", r.previewId("deleted-id"))
+	assert.Equal(t, "", r.previewId("missing-id"))
+}
+
+func TestRenderPreviews(t *testing.T) {
+	t.Run("link and mention reference preview", func(t *testing.T) {
+		// given
+		mention := textBlockWithMark(model.BlockContentTextMark_Mention, "target-id")
+		mention.Id = "mention"
+		r := newPageRenderer(mention, linkBlock("link", "target-id"))
+		r.CachedPbFiles = previewSnapshots()
+		r.hydratePreviews()
+
+		// when
+		linkParams := r.makeLinkBlockParams(r.BlocksById["link"])
+		textParams := r.makeTextBlockParams(mention)
+
+		// then
+		linkTag, err := blockParamsToHtmlTag(linkParams)
+		assert.NoError(t, err)
+		assertHtmlTag(t, linkTag, []pathAssertion{
+			{"a.linkCard > attrs[data-preview-id]", "target-id"},
+		})
+		textTag, err := blockParamsToHtmlTag(textParams)
+		assert.NoError(t, err)
+		assertHtmlTag(t, textTag, []pathAssertion{
+			{"div.flex > div.text > a.markupmention > attrs[data-preview-id]", "target-id"},
+		})
+	})
+	t.Run("preview cards", func(t *testing.T) {
+		// given
+		r := newPageRenderer(linkBlock("link", "target-id"))
+		r.CachedPbFiles = previewSnapshots()
+		r.hydratePreviews()
+
+		// when
+		builder := strings.Builder{}
+		err := r.RenderPreviews().Render(context.Background(), &builder)
+
+		// then
+		assert.NoError(t, err)
+		tag, err := htmltag.HtmlToTag(builder.String())
+		assert.NoError(t, err)
+		card := "div.previews > template > div.previewCard > div.content"
+		assertHtmlTag(t, tag, []pathAssertion{
+			{"div.previews > template > attrs[id]", "preview-target-id"},
+			{card + " > div.name > Content", "Target"},
+			{card + " > div.description > Content", "Snippet text"},
+			{card + " > div.type > Content", "Book"},
+		})
+	})
+	t.Run("no references", func(t *testing.T) {
+		// given
+		r := newPageRenderer(textBlock("text", model.BlockContentText_Paragraph, "text"))
+		r.hydratePreviews()
+
+		// when
+		builder := strings.Builder{}
+		err := r.RenderPreviews().Render(context.Background(), &builder)
+
+		// then
+		assert.NoError(t, err)
+		assert.Empty(t, builder.String())
+	})
+}
@@ -166,8 +166,11 @@ func (r *Renderer) populateRelationValue(params *RelationRenderSetting, format m
 	case model.RelationFormat_number:
 		return BasicTemplate("name", fmt.Sprintf("%g", relationValue.GetNumberValue()))
 	case model.RelationFormat_phone, model.RelationFormat_email, model.RelationFormat_url:
-		url := getUrlScheme(format, relationValue.GetStringValue()) + relationValue.GetStringValue()
-		return ObjectElement(relationValue.GetStringValue(), templ.URL(url))
+		url, ok := r.sanitizeUrl(getUrlScheme(format, relationValue.GetStringValue()) + relationValue.GetStringValue())
+		if !ok {
+			return BasicTemplate("name", relationValue.GetStringValue())
+		}
+		return ObjectElement(relationValue.GetStringValue(), ResolvedLink{Url: url, Rel: linkRel})
 	case model.RelationFormat_date:
 		return BasicTemplate("name", r.formatDate(relationValue.GetNumberValue()))
 	case model.RelationFormat_checkbox:
@@ -285,8 +288,8 @@ func (r *Renderer) generateObjectLinks(relationValue *types.Value) []templ.Compo
 			name = defaultName
 		}
 		icon := r.getIconFromDetails(details)
-		link := r.makeAnytypeLink(details, objectId)
-		elements = append(elements, ListElement(ObjectElement(name, templ.SafeURL(link)), icon))
+		link := r.resolveLink(LinkKindRelation, details, objectId)
+		elements = append(elements, ListElement(ObjectElement(name, link), icon))
 	}
 	return elements
 }
@@ -298,12 +301,16 @@ func (r *Renderer) generateFileComponent(relationValue *types.Value) []templ.Com
 		if err != nil {
 			continue
 		}
+		url, ok := r.sanitizeUrl(url)
+		if !ok {
+			continue
+		}
 		fileBlock, err := r.getFileBlock(value.GetStringValue())
 		if err != nil {
 			continue
 		}
 		icon := r.createFileIcon(fileBlock)
-		elements = append(elements, ListElement(ObjectElement(filepath.Base(url), templ.URL(url)), icon))
+		elements = append(elements, ListElement(ObjectElement(filepath.Base(url), ResolvedLink{Url: url, Rel: linkRel}), icon))
 	}
 	return elements
 }
@@ -556,3 +556,65 @@ func TestMakeRelationRenderParams_ShortText(t *testing.T) {
 		assert.Equal(t, `<div class="sides"><div class="info"><div class="name">Text Relation</div></div></div>`, builder.String())
 	})
 }
+
+func TestMakeRelationRenderParams_Url(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   model.RelationFormat
+		value    string
+		expected string
+	}{
+		{
+			name:     "url",
+			format:   model.RelationFormat_url,
+			value:    "https://example.com",
+			expected: `<div class="name"><a href="https://example.com" rel="noopener noreferrer nofollow">https://example.com</a></div>`,
+		},
+		{
+			name:     "url without scheme",
+			format:   model.RelationFormat_url,
+			value:    "example.com",
+			expected: `<div class="name"><a href="http://example.com" rel="noopener noreferrer nofollow">example.com</a></div>`,
+		},
+		{
+			name:     "email",
+			format:   model.RelationFormat_email,
+			value:    "a@example.com",
+			expected: `<div class="name"><a href="mailto:a@example.com" rel="noopener noreferrer nofollow">a@example.com</a></div>`,
+		},
+		{
+			name:     "phone",
+			format:   model.RelationFormat_phone,
+			value:    "+12345",
+			expected: `<div class="name"><a href="tel:+12345" rel="noopener noreferrer nofollow">+12345</a></div>`,
+		},
+		{
+			name:     "javascript url",
+			format:   model.RelationFormat_url,
+			value:    "javascript:alert(1)",
+			expected: `<div class="name">javascript:alert(1)</div>`,
+		},
+		{
+			name:     "data url",
+			format:   model.RelationFormat_url,
+			value:    "data:text/html,<script>alert(1)</script>",
+			expected: `<div class="name">data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			r := NewTestRenderer()
+
+			// when
+			component := r.populateRelationValue(&RelationRenderSetting{}, tt.format, pbtypes.String(tt.value))
+
+			// then
+			builder := strings.Builder{}
+			err := component.Render(context.Background(), &builder)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, builder.String())
+		})
+	}
+}
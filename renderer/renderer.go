@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
 
 	"github.com/a-h/templ"
@@ -52,11 +53,42 @@ type RenderConfig struct {
 	// anytype cdn, only for emojies for now
 	AnytypeCdnUrl string
 
+	EmojiMode EmojiMode
+	// directory with emoji images for EmojiModeLocal
+	EmojiAssetsPath string
+
 	// analytics code to inject
 	AnalyticsCode string
 
 	// classes for <html> tag, used for debug
 	HtmlClasses []string
+
+	// render lists, toggles, quotes and checkboxes with semantic html elements
+	// instead of generic blocks, for screen readers and reader modes
+	SemanticHtml bool
+
+	// page language, detected from the text if empty
+	Lang string
+
+	// embed providers settings
+	Embeds EmbedsConfig
+
+	// url schemes allowed in links from published content,
+	// DefaultAllowedUrlSchemes if empty
+	AllowedUrlSchemes []string
+
+	// resized copies of images for srcset, images have a single src if nil
+	ImageDerivatives ImageDerivativesFunc
+
+	// urls of referenced objects, AnytypeLinkResolver if nil
+	LinkResolver LinkResolver
+
+	// export page as one html file with inlined assets, nil for regular pages.
+	// Analytics code and image derivatives are not used then
+	SingleFile *SingleFileConfig
+	// export page into a directory with its assets, nil for regular pages.
+	// Such pages are written with Export instead of Render
+	Export *ExportConfig
 }
 
 type Renderer struct {
@@ -70,10 +102,30 @@ type Renderer struct {
 	Root       *model.Block
 	BlocksById map[string]*model.Block
 
-	BlockNumbers      map[string]int
+	BlockNumbers map[string]int
+	// nesting level of numbered blocks, which defines marker style
+	BlockNumberLevels map[string]int
+	// heading block id -> slug anchor
+	HeadingSlugs map[string]string
+	// referenced object id -> preview card, PreviewIds keeps page order
+	Previews   map[string]*ObjectPreview
+	PreviewIds []string
+	// image block id -> its place in gallery of consecutive images
+	Galleries         map[string]GalleryItem
 	ObjectTypeDetails *types.Struct
 	ResolvedLayout    model.ObjectTypeLayout
 	LayoutAlign       int64
+	// dominant text direction of the page, "ltr" or "rtl"
+	Direction string
+	Lang      string
+
+	// bookmark source url -> picture file id, to show thumbnails of embeds
+	sourcePictures  map[string]string
+	embedProcessors *EmbedRegistry
+	// .vtt files of the package, for video subtitles
+	subtitles []packageFile
+	// inlined into single file pages
+	build *staticBuild
 }
 
 func readJsonpbSnapshot(snapshotStr string) (snapshot pb.SnapshotWithType, err error) {
@@ -212,7 +264,6 @@ func NewRenderer(config RenderConfig) (r *Renderer, err error) {
 		UberSp:        &uberSnapshot,
 		CachedPbFiles: make(map[string]*pb.SnapshotWithType),
 		BlocksById:    blocksById,
-		BlockNumbers:  make(map[string]int),
 		Root:          blocks[0],
 		Config:        config,
 	}
@@ -227,9 +278,13 @@ func NewRenderer(config RenderConfig) (r *Renderer, err error) {
 	r.ResolvedLayout = r.resolveObjectLayout(snapshot.Snapshot.GetData().GetDetails())
 
 	r.fillLayoutAlign(snapshot.Snapshot.GetData().GetDetails())
+	r.hydrateDirection()
 	r.maybeAddDebugCss()
 	r.hydrateSpecialBlocks()
 	r.hydrateNumberBlocks()
+	r.hydrateHeadingSlugs()
+	r.hydratePreviews()
+	r.hydrateGalleries()
 	r.RootComp = r.RenderPage()
 
 	return
@@ -237,16 +292,47 @@ func NewRenderer(config RenderConfig) (r *Renderer, err error) {
 
 // asset resolver parts
 
-func (r *Renderer) GetEmojiUrl(code rune) string {
-	return fmt.Sprintf("%s/emojies/%x.png", r.Config.AnytypeCdnUrl, code)
+// AssetOrigin is a place assets of the page are served from
+type AssetOrigin int
+
+const (
+	// RenderConfig.StaticFilesPath, common for all pages
+	AssetOriginStatic AssetOrigin = iota
+	// RenderConfig.PublishFilesPath, files of the published package
+	AssetOriginPackage
+	// RenderConfig.EmojiAssetsPath
+	AssetOriginEmoji
+	// RenderConfig.AnytypeCdnUrl
+	AssetOriginAnytypeCdn
+	// RenderConfig.PrismJsCdnUrl
+	AssetOriginPrismJs
+)
+
+// assetUrl is the only place urls of assets are made, so that pages can be
+// served under a subpath or with assets on another domain
+func (r *Renderer) assetUrl(origin AssetOrigin, path string) string {
+	var base string
+	switch origin {
+	case AssetOriginStatic:
+		base = r.Config.StaticFilesPath
+	case AssetOriginPackage:
+		base = r.Config.PublishFilesPath
+	case AssetOriginEmoji:
+		base = r.Config.EmojiAssetsPath
+	case AssetOriginAnytypeCdn:
+		base = r.Config.AnytypeCdnUrl
+	case AssetOriginPrismJs:
+		base = r.Config.PrismJsCdnUrl
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
 }
 
 func (r *Renderer) GetStaticFolderUrl(filepath string) string {
-	return fmt.Sprintf("%s%s", r.Config.StaticFilesPath, filepath)
+	return r.assetUrl(AssetOriginStatic, filepath)
 }
 
 func (r *Renderer) GetPrismJsUrl(filepath string) string {
-	return fmt.Sprintf("%s%s", r.Config.PrismJsCdnUrl, filepath)
+	return r.assetUrl(AssetOriginPrismJs, filepath)
 }
 
 func (r *Renderer) Render(writer io.Writer) (err error) {
@@ -258,6 +344,10 @@ func (r *Renderer) Render(writer io.Writer) (err error) {
 		}
 	}()
 
+	if r.isSingleFile() {
+		return r.renderSingleFile(writer)
+	}
+
 	err = r.RootComp.Render(context.Background(), writer)
 	if err != nil {
 		return
@@ -287,61 +377,124 @@ func (r *Renderer) ReadJsonpbSnapshot(path string) (*pb.SnapshotWithType, error)
 	return &newSnapshot, nil
 }
 
+// layouts which are only visual grouping, lists continue through them
+func isTransparentLayout(b *model.Block) bool {
+	layout := b.GetLayout()
+	if layout == nil {
+		return false
+	}
+	switch layout.GetStyle() {
+	case model.BlockContentLayout_Row, model.BlockContentLayout_Column, model.BlockContentLayout_Div:
+		return true
+	}
+	return false
+}
+
+// unwrapLayouts replaces rows, columns and divs with their content, recursively
 func (r *Renderer) unwrapLayouts(blocks []*model.Block) []*model.Block {
 	ret := make([]*model.Block, 0)
 	for _, b := range blocks {
-		layout := b.GetLayout()
-
-		if layout == nil || layout.GetStyle() != model.BlockContentLayout_Div {
-			ret = append(ret, b)
+		if b == nil {
 			continue
 		}
-
-		chBlocks := make([]*model.Block, len(b.ChildrenIds))
-		for i, chId := range b.ChildrenIds {
-			chBlocks[i] = r.BlocksById[chId]
+		if !isTransparentLayout(b) {
+			ret = append(ret, b)
+			continue
 		}
 
-		ret = append(ret, chBlocks...)
+		ret = append(ret, r.unwrapLayouts(r.childBlocks(b))...)
 	}
 
 	return ret
 }
 
-func (r *Renderer) hydrateNumberBlocksInner(blocks []*model.Block) {
+func (r *Renderer) hydrateNumberBlocksInner(blocks []*model.Block, level int) {
 	unwrapped := r.unwrapLayouts(blocks)
 	prevNumber := 0
 
 	for _, b := range unwrapped {
-		if t := b.GetText(); t != nil {
-			if t.GetStyle() == model.BlockContentText_Numbered {
-				if _, ok := r.BlockNumbers[b.Id]; !ok {
-					prevNumber++
-					r.BlockNumbers[b.Id] = prevNumber
-				}
-			} else {
-				prevNumber = 0
-			}
-		}
-
-		layout := b.GetLayout()
-		if layout != nil || layout.GetStyle() == model.BlockContentLayout_Div {
-			continue
+		// any other block, not only text, breaks the list
+		if b.GetText().GetStyle() == model.BlockContentText_Numbered {
+			prevNumber++
+			r.BlockNumbers[b.Id] = prevNumber
+			r.BlockNumberLevels[b.Id] = level
+		} else {
+			prevNumber = 0
 		}
 
 		if len(b.ChildrenIds) > 0 {
-			chBlocks := make([]*model.Block, len(b.ChildrenIds))
-			for i, chId := range b.ChildrenIds {
-				chBlocks[i] = r.BlocksById[chId]
+			// children of any block are one level deeper, including tables and toggles,
+			// only layout blocks (row, column, div, page header, table rows and columns) keep the level
+			childLevel := level
+			if b.GetLayout() == nil {
+				childLevel++
 			}
-			r.hydrateNumberBlocksInner(chBlocks)
+			r.hydrateNumberBlocksInner(r.childBlocks(b), childLevel)
 		}
 	}
 }
 
 // Adds numbers as marks to text blocks with type "number"
 func (r *Renderer) hydrateNumberBlocks() {
-	r.hydrateNumberBlocksInner(r.Sp.Snapshot.Data.GetBlocks())
+	r.BlockNumbers = make(map[string]int)
+	r.BlockNumberLevels = make(map[string]int)
+	// root is not a list item itself, so its children start from the top level
+	r.hydrateNumberBlocksInner(r.childBlocks(r.Root), 0)
+}
+
+func (r *Renderer) childBlocks(b *model.Block) []*model.Block {
+	chBlocks := make([]*model.Block, len(b.GetChildrenIds()))
+	for i, chId := range b.GetChildrenIds() {
+		chBlocks[i] = r.BlocksById[chId]
+	}
+	return chBlocks
+}
+
+var romanNumerals = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+	{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+}
+
+func toRoman(n int) string {
+	var sb strings.Builder
+	for _, numeral := range romanNumerals {
+		for n >= numeral.value {
+			sb.WriteString(numeral.symbol)
+			n -= numeral.value
+		}
+	}
+	return sb.String()
+}
+
+// toAlpha counts like spreadsheet columns: a..z, aa..az, ba..
+func toAlpha(n int) string {
+	var letters []byte
+	for n > 0 {
+		n--
+		letters = append([]byte{byte('a' + n%26)}, letters...)
+		n /= 26
+	}
+	return string(letters)
+}
+
+// formatListNumber formats number the same way as the editor does:
+// decimal, then alpha, then roman by nesting level, repeating after that
+func formatListNumber(number, level int) string {
+	switch level % 3 {
+	case 1:
+		return toAlpha(number)
+	case 2:
+		return toRoman(number)
+	default:
+		return strconv.Itoa(number)
+	}
+}
+
+func (r *Renderer) listMarker(blockId string) string {
+	return formatListNumber(r.BlockNumbers[blockId], r.BlockNumberLevels[blockId])
 }
 
 // Adds text from Details to special blocks like `title`
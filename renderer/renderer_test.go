@@ -89,3 +89,28 @@ func WithObjectTypeDetails(details *types.Struct) Option {
 		t.ObjectTypeDetails = details
 	}
 }
+
+func TestAssetUrl(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RenderConfig
+		origin   AssetOrigin
+		path     string
+		expected string
+	}{
+		{"static", RenderConfig{StaticFilesPath: "/static"}, AssetOriginStatic, "/img/space.svg", "/static/img/space.svg"},
+		{"static under subpath", RenderConfig{StaticFilesPath: "/docs/static/"}, AssetOriginStatic, "/js/build/", "/docs/static/js/build/"},
+		{"static on cdn", RenderConfig{StaticFilesPath: "https://cdn.example.com"}, AssetOriginStatic, "/embed/iframe.html", "https://cdn.example.com/embed/iframe.html"},
+		{"static at root", RenderConfig{}, AssetOriginStatic, "/img/og-image.png", "/img/og-image.png"},
+		{"package file", RenderConfig{PublishFilesPath: "https://files.example.com/page"}, AssetOriginPackage, "files/a.png", "https://files.example.com/page/files/a.png"},
+		{"local emoji", RenderConfig{EmojiAssetsPath: "/emoji/"}, AssetOriginEmoji, "1f600.png", "/emoji/1f600.png"},
+		{"cdn emoji", RenderConfig{AnytypeCdnUrl: "https://cdn.example.com"}, AssetOriginAnytypeCdn, "emojies/1f600.png", "https://cdn.example.com/emojies/1f600.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewTestRenderer(WithConfig(tt.config))
+
+			assert.Equal(t, tt.expected, r.assetUrl(tt.origin, tt.path))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package renderer
+
+import (
+	"context"
+	"io"
+
+	"github.com/a-h/templ"
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+)
+
+// listGroup is a run of consecutive list items of the same kind
+type listGroup struct {
+	Ordered bool
+	// number of the first item, for lists continued after a layout
+	Start int
+	Ids   []string
+}
+
+func listItemKind(b *model.Block) (isList bool, ordered bool) {
+	switch b.GetText().GetStyle() {
+	case model.BlockContentText_Marked:
+		return true, false
+	case model.BlockContentText_Numbered:
+		return true, true
+	}
+	return false, false
+}
+
+// RenderBlocks renders sibling blocks. In semantic mode consecutive
+// bulleted and numbered blocks are grouped into <ul> and <ol>.
+func (r *Renderer) RenderBlocks(ids []string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if !r.Config.SemanticHtml {
+			for _, id := range ids {
+				if err := r.RenderBlock(id).Render(ctx, w); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var group *listGroup
+		flush := func() error {
+			if group == nil {
+				return nil
+			}
+			err := SemanticListTemplate(r, group).Render(ctx, w)
+			group = nil
+			return err
+		}
+
+		for _, id := range ids {
+			isList, ordered := listItemKind(r.BlocksById[id])
+			if group != nil && (!isList || group.Ordered != ordered) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if !isList {
+				if err := r.RenderBlock(id).Render(ctx, w); err != nil {
+					return err
+				}
+				continue
+			}
+			if group == nil {
+				group = &listGroup{Ordered: ordered, Start: r.BlockNumbers[id]}
+			}
+			group.Ids = append(group.Ids, id)
+		}
+		return flush()
+	})
+}
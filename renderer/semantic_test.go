@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func renderToString(t *testing.T, r *TestRenderer, ids []string) string {
+	builder := strings.Builder{}
+	err := r.RenderBlocks(ids).Render(context.Background(), &builder)
+	assert.NoError(t, err)
+	return builder.String()
+}
+
+func TestRenderBlocks(t *testing.T) {
+	blocks := []*model.Block{
+		textBlock("b1", model.BlockContentText_Marked, "bullet 1"),
+		textBlock("b2", model.BlockContentText_Marked, "bullet 2"),
+		textBlock("n1", model.BlockContentText_Numbered, "number 1"),
+		textBlock("p", model.BlockContentText_Paragraph, "paragraph"),
+		textBlock("n2", model.BlockContentText_Numbered, "number 2"),
+	}
+	ids := []string{"b1", "b2", "n1", "p", "n2"}
+
+	t.Run("semantic mode groups list items", func(t *testing.T) {
+		// given
+		r := newPageRenderer(blocks...)
+		r.Config.SemanticHtml = true
+		r.hydrateNumberBlocks()
+
+		// when
+		html := renderToString(t, r, ids)
+
+		// then
+		assert.Equal(t, 1, strings.Count(html, `<ul class="semanticList">`))
+		assert.Equal(t, 2, strings.Count(html, `<ol class="semanticList">`))
+		assert.Equal(t, 4, strings.Count(html, `<li>`))
+		assert.Less(t, strings.Index(html, `</ul>`), strings.Index(html, `id="n1"`))
+		assert.Less(t, strings.Index(html, `</ol>`), strings.Index(html, `id="p"`))
+	})
+	t.Run("continued numbered list starts from its number", func(t *testing.T) {
+		// given
+		r := newPageRenderer(blocks...)
+		r.Config.SemanticHtml = true
+		r.BlockNumbers = map[string]int{"n1": 3}
+
+		// when
+		html := renderToString(t, r, []string{"n1"})
+
+		// then
+		assert.Contains(t, html, `<ol class="semanticList" start="3"><li><div id="n1"`)
+	})
+	t.Run("default mode renders blocks as is", func(t *testing.T) {
+		// given
+		r := newPageRenderer(blocks...)
+
+		// when
+		html := renderToString(t, r, ids)
+
+		// then
+		assert.NotContains(t, html, "<li>")
+		assert.True(t, strings.HasPrefix(html, `<div id="b1"`))
+	})
+}
+
+func TestMakeTextBlockParamsSemantic(t *testing.T) {
+	r := NewTestRenderer(WithConfig(RenderConfig{SemanticHtml: true}))
+
+	t.Run("toggle", func(t *testing.T) {
+		// given
+		toggle := textBlock("toggle", model.BlockContentText_Toggle, "toggle")
+		toggle.ChildrenIds = []string{"child"}
+		r := newPageRenderer(toggle)
+		r.Config.SemanticHtml = true
+		r.BlocksById["child"] = textBlock("child", model.BlockContentText_Paragraph, "child")
+
+		// when
+		html := renderToString(t, r, []string{"toggle"})
+
+		// then
+		assert.True(t, strings.HasPrefix(html, `<details id="toggle" class="block align0 blockText textToggle">`))
+		assert.Contains(t, html, `<summary class="content">`)
+		assert.Contains(t, html, `<div class="children"><div id="child"`)
+	})
+	t.Run("quote", func(t *testing.T) {
+		// when
+		actual := r.makeTextBlockParams(textBlock("quote", model.BlockContentText_Quote, "quote"))
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		assertHtmlTag(t, tag, []pathAssertion{
+			{"div.flex > blockquote.text > Content", "quote"},
+		})
+	})
+	t.Run("checkbox", func(t *testing.T) {
+		// given
+		block := textBlock("check", model.BlockContentText_Checkbox, "done")
+		block.GetText().Checked = true
+
+		// when
+		actual := r.makeTextBlockParams(block)
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		assertHtmlTag(t, tag, []pathAssertion{
+			{"div.flex > div.markers > div.marker > input.checkboxInput > attrs[id]", "checkbox-check"},
+			{"div.flex > div.markers > div.marker > input.checkboxInput > attrs[checked]", ""},
+			{"div.flex > div.markers > div.marker > input.checkboxInput > attrs[disabled]", ""},
+			{"div.flex > label.text > attrs[for]", "checkbox-check"},
+			{"div.flex > label.text > Content", "done"},
+		})
+		assert.Contains(t, actual.Classes, "isChecked")
+	})
+}
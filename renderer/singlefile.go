@@ -0,0 +1,153 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/a-h/templ"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultAssetBudget = 1 << 20
+	DefaultPageBudget  = 10 << 20
+)
+
+// SingleFileConfig describes export of a page into one html file without external requests.
+// Static build and package files are inlined, so StaticFilesPath and PublishFilesPath
+// must be local directories. Third-party embeds are still loaded from their providers.
+type SingleFileConfig struct {
+	// inlined assets bigger than this are reported, DefaultAssetBudget if 0
+	AssetBudget int64
+	// pages bigger than this are reported, DefaultPageBudget if 0
+	PageBudget int64
+}
+
+// staticBuild is css and js which loader.js adds to regular pages
+type staticBuild struct {
+	Css string
+	Js  string
+}
+
+var (
+	sourceMapRe   = regexp.MustCompile(`(?m)^\s*(//|/\*)# sourceMappingURL=.*$`)
+	closingRawTag = regexp.MustCompile(`(?i)</(script|style)`)
+)
+
+func (r *Renderer) isSingleFile() bool {
+	return r.Config.SingleFile != nil
+}
+
+// getStaticBuild reads files listed in loader.js of StaticFilesPath
+func (r *Renderer) getStaticBuild() (*staticBuild, error) {
+	if r.build != nil {
+		return r.build, nil
+	}
+	css, js, err := r.staticBuildFiles()
+	if err != nil {
+		return nil, err
+	}
+	build := &staticBuild{}
+	if build.Css, err = r.readBuildFiles(css); err != nil {
+		return nil, err
+	}
+	if build.Js, err = r.readBuildFiles(js); err != nil {
+		return nil, err
+	}
+	r.build = build
+	return build, nil
+}
+
+func (r *Renderer) readBuildFiles(names []string) (string, error) {
+	var contents []string
+	for _, name := range names {
+		data, err := os.ReadFile(r.staticBuildPath(name))
+		if err != nil {
+			return "", fmt.Errorf("failed to read static build: %w", err)
+		}
+		// source maps are separate files, devtools would request them
+		contents = append(contents, sourceMapRe.ReplaceAllString(string(data), ""))
+	}
+	return strings.Join(contents, "\n"), nil
+}
+
+// escapeRawText keeps inlined css and js from closing their element
+func escapeRawText(text string) string {
+	return closingRawTag.ReplaceAllString(text, `<\/$1`)
+}
+
+// RenderInlineStyles renders css of static build for single file pages
+func (r *Renderer) RenderInlineStyles() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		build, err := r.getStaticBuild()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, `<style type="text/css">`+escapeRawText(build.Css)+`</style>`)
+		return err
+	})
+}
+
+// RenderInlineScripts renders js of static build for single file pages
+func (r *Renderer) RenderInlineScripts() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		build, err := r.getStaticBuild()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, `<script type="text/javascript">`+escapeRawText(build.Js)+`</script>`)
+		return err
+	})
+}
+
+func (r *Renderer) renderSingleFile(writer io.Writer) error {
+	var buffer bytes.Buffer
+	if err := r.RootComp.Render(context.Background(), &buffer); err != nil {
+		return err
+	}
+	page, err := r.newAssetInliner().rewriteHtml(buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to inline assets: %w", err)
+	}
+
+	budget := r.Config.SingleFile.PageBudget
+	if budget <= 0 {
+		budget = DefaultPageBudget
+	}
+	if int64(len(page)) > budget {
+		log.Warn("single file page is over budget", zap.Int("size", len(page)), zap.Int64("budget", budget))
+	}
+	_, err = writer.Write(page)
+	return err
+}
+
+// assetInliner replaces urls of local files with data uris
+type assetInliner struct {
+	*assetRewriter
+	budget int64
+	// urls of inlined assets over budget
+	oversized []string
+}
+
+func (r *Renderer) newAssetInliner() *assetInliner {
+	in := &assetInliner{budget: r.Config.SingleFile.AssetBudget}
+	if in.budget <= 0 {
+		in.budget = DefaultAssetBudget
+	}
+	in.assetRewriter = r.newAssetRewriter(false, in.dataUri)
+	return in
+}
+
+func (in *assetInliner) dataUri(asset localAsset) (string, error) {
+	if int64(len(asset.Data)) > in.budget {
+		log.Warn("inlined asset is over budget", zap.String("path", asset.Path), zap.Int("size", len(asset.Data)), zap.Int64("budget", in.budget))
+		in.oversized = append(in.oversized, asset.Url)
+	}
+	return "data:" + assetMimeType(asset.Path, asset.Data) + ";base64," + base64.StdEncoding.EncodeToString(asset.Data), nil
+}
@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/anytype-publish-renderer/utils"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func writeTestStaticBuild(t *testing.T, dir string) {
+	writeTestFile(t, filepath.Join(dir, "js", "loader.js"), `(function() {
+	const chunks = ["main.1a2b.js"];
+	const cssFiles = ["main.3c4d.css"];
+})();`)
+	writeTestFile(t, filepath.Join(dir, "js", "build", "main.1a2b.js"), "console.log('</script>');\n//# sourceMappingURL=main.1a2b.js.map")
+	writeTestFile(t, filepath.Join(dir, "js", "build", "main.3c4d.css"), "body { opacity: 1; }\n/*# sourceMappingURL=main.3c4d.css.map*/")
+}
+
+func TestStaticBuild(t *testing.T) {
+	t.Run("build files are inlined", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		writeTestStaticBuild(t, dir)
+		r := NewTestRenderer(WithConfig(RenderConfig{StaticFilesPath: dir, SingleFile: &SingleFileConfig{}}))
+
+		// when
+		styles, err := utils.TemplToString(r.RenderInlineStyles())
+		require.NoError(t, err)
+		scripts, err := utils.TemplToString(r.RenderInlineScripts())
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, "<style type=\"text/css\">body { opacity: 1; }\n</style>", styles)
+		assert.Equal(t, "<script type=\"text/javascript\">console.log('<\\/script>');\n</script>", scripts)
+	})
+	t.Run("js is not built", func(t *testing.T) {
+		// given
+		dir := t.TempDir()
+		writeTestFile(t, filepath.Join(dir, "js", "loader.js"), "const chunks = %CHUNKS%;")
+		r := NewTestRenderer(WithConfig(RenderConfig{StaticFilesPath: dir, SingleFile: &SingleFileConfig{}}))
+
+		// when
+		_, err := utils.TemplToString(r.RenderInlineScripts())
+
+		// then
+		assert.Error(t, err)
+	})
+}
+
+func TestAssetInliner(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	static, files := filepath.Join(dir, "static"), filepath.Join(dir, "package")
+	writeTestFile(t, filepath.Join(static, "img", "icon.svg"), "<svg></svg>")
+	writeTestFile(t, filepath.Join(files, "files", `a\b.vtt`), "WEBVTT")
+	writeTestFile(t, filepath.Join(files, "files", "cover.png"), strings.Repeat("x", 100))
+	writeTestFile(t, filepath.Join(dir, "secret.txt"), "secret")
+	r := NewTestRenderer(WithConfig(RenderConfig{
+		StaticFilesPath:  static,
+		PublishFilesPath: files,
+		SingleFile:       &SingleFileConfig{AssetBudget: 50},
+	}))
+	inliner := r.newAssetInliner()
+	svgUri := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte("<svg></svg>"))
+
+	// when
+	page, err := inliner.rewriteHtml([]byte(`<div class="svg-container" data-src="` + static + `/img/icon.svg"></div>` +
+		`<img src="` + static + `/img/icon.svg" class="icon">` +
+		`<track kind="subtitles" src="` + files + `/files/a%5Cb.vtt">` +
+		`<div class="cover" style="background-image: url(` + files + `/files/cover.png)"></div>` +
+		`<img src="` + static + `/../secret.txt"><img src="https://example.com/a.png"><img src="` + static + `/img/missing.svg">` +
+		`<script>const html = '<img src="` + static + `/img/icon.svg">';</script>`))
+	require.NoError(t, err)
+	html := string(page)
+
+	// then
+	assert.Contains(t, html, `<div class="svg-container" data-src="`+svgUri+`"></div>`)
+	assert.Contains(t, html, `<img src="`+svgUri+`" class="icon">`)
+	assert.Contains(t, html, `src="data:text/vtt;base64,`+base64.StdEncoding.EncodeToString([]byte("WEBVTT"))+`"`)
+	assert.Contains(t, html, `style="background-image: url(&#34;data:image/png;base64,`)
+	assert.Contains(t, html, `<img src="`+static+`/../secret.txt"><img src="https://example.com/a.png"><img src="`+static+`/img/missing.svg">`)
+	assert.Contains(t, html, `<script>const html = '<img src="`+static+`/img/icon.svg">';</script>`)
+	assert.Equal(t, []string{files + "/files/cover.png"}, inliner.oversized)
+}
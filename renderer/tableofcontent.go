@@ -27,7 +27,6 @@ func (r *Renderer) makeTableOfContentBlockParams(block *model.Block) *BlockParam
 func (r *Renderer) getList() []templ.Component {
 	blocks := r.traverseBlocks(r.BlocksById, r.Root.GetId(), false)
 	var list []templ.Component
-	styles := []model.BlockContentTextStyle{model.BlockContentText_Header1, model.BlockContentText_Header2, model.BlockContentText_Header3}
 
 	var hasH1, hasH2 bool
 
@@ -40,7 +39,7 @@ func (r *Renderer) getList() []templ.Component {
 
 		style := text.GetStyle()
 
-		if !contains(styles, style) {
+		if !contains(slugHeaderStyles, style) {
 			continue
 		}
 
@@ -69,7 +68,11 @@ func (r *Renderer) getList() []templ.Component {
 		}
 
 		name := r.getHeadingName(bl.Block)
-		list = append(list, HeadingTemplate(bl.GetId(), name, depth))
+		anchor := r.headingSlug(bl.GetId())
+		if anchor == "" {
+			anchor = bl.GetId()
+		}
+		list = append(list, HeadingTemplate(anchor, name, depth))
 	}
 
 	return list
@@ -73,7 +73,11 @@ func (r *Renderer) applyMark(style model.BlockContentTextStyle, s string, mark *
 		return "<markupunderline>" + s + "</markupunderline>"
 
 	case model.BlockContentTextMark_Link:
-		return fmt.Sprintf(`<a href="%s" class="markuplink" target="_blank">`, mark.Param) + s + "</a>"
+		link, ok := r.sanitizeUrl(mark.Param)
+		if !ok {
+			return `<markupobject class="disabled">` + s + "</markupobject>"
+		}
+		return markupLink(link, s)
 
 	case model.BlockContentTextMark_TextColor:
 		color := mark.Param
@@ -97,8 +101,8 @@ func (r *Renderer) applyMark(style model.BlockContentTextStyle, s string, mark *
 			if iconParams.SvgSrc != "" {
 				iconParams.IconClasses = append(iconParams.IconClasses, "withSvg")
 			}
-			link := r.makeAnytypeLink(details, mark.Param)
-			html, err := utils.TemplToString(TextMarkupMention(r, templ.SafeURL(link), s, classes, iconParams))
+			link := r.resolveLink(LinkKindMention, details, mark.Param)
+			html, err := utils.TemplToString(TextMarkupMention(r, link, r.previewId(mark.Param), s, classes, iconParams))
 			if err != nil {
 				log.Error("Failed to render mention icon", zap.Error(err))
 			}
@@ -109,9 +113,12 @@ func (r *Renderer) applyMark(style model.BlockContentTextStyle, s string, mark *
 		return ""
 
 	case model.BlockContentTextMark_Emoji:
-		code := []rune(mark.Param)[0]
-		emojiSrc := r.GetEmojiUrl(code)
-		emojiHtml, err := utils.TemplToString(InlineEmojiTemplate(emojiSrc, fmt.Sprintf("c%d", emojiSize)))
+		emoji := firstEmoji(mark.Param)
+		if emoji == "" {
+			return s
+		}
+		emojiSrc := r.GetEmojiUrl(emoji)
+		emojiHtml, err := utils.TemplToString(InlineEmojiTemplate(emoji, emojiSrc, fmt.Sprintf("c%d", emojiSize)))
 		if err != nil {
 			log.Error("Failed to render emoji template", zap.Error(err))
 			return ""
@@ -123,13 +130,34 @@ func (r *Renderer) applyMark(style model.BlockContentTextStyle, s string, mark *
 		if details == nil || len(details.Fields) == 0 {
 			return "<markupobject>" + s + "</markupobject>"
 		}
-		link := r.makeAnytypeLink(details, mark.Param)
-		return fmt.Sprintf(`<a href="%s" class="markuplink" target="_blank">`, link) + s + "</a>"
+		link := r.resolveLink(LinkKindObjectMark, details, mark.Param)
+		if link.Url == "" {
+			return "<markupobject>" + s + "</markupobject>"
+		}
+		return markupObjectLink(link, r.previewId(mark.Param), s)
 	}
 
 	return "<markupobject>" + s + "</markupobject>"
 }
 
+func markupLink(link, s string) string {
+	return markupObjectLink(ResolvedLink{Url: link, Target: "_blank", Rel: linkRel}, "", s)
+}
+
+func markupObjectLink(link ResolvedLink, previewId, s string) string {
+	attrs := fmt.Sprintf(`href="%s" class="markuplink"`, html.EscapeString(link.Url))
+	if link.Target != "" {
+		attrs += fmt.Sprintf(` target="%s"`, html.EscapeString(link.Target))
+	}
+	if link.Rel != "" {
+		attrs += fmt.Sprintf(` rel="%s"`, html.EscapeString(link.Rel))
+	}
+	if previewId != "" {
+		attrs += fmt.Sprintf(` data-preview-id="%s"`, html.EscapeString(previewId))
+	}
+	return "<a " + attrs + ">" + s + "</a>"
+}
+
 // Convert a string into "JS-like" rune slices (surrogate pairs split)
 //
 // When we get Range from anytype-ts, it is calculates emojies by codepoints.
@@ -265,6 +293,7 @@ func (r *Renderer) makeTextBlockParams(b *model.Block) (params *BlockParams) {
 	classes := []string{"text" + style.String()}
 
 	blockParams := makeDefaultBlockParams(b)
+	blockParams.Dir = r.blockDirection(blockText.GetText())
 	if bgColor != "" {
 		if (style == model.BlockContentText_Callout) ||
 			(style == model.BlockContentText_Quote) {
@@ -287,7 +316,11 @@ func (r *Renderer) makeTextBlockParams(b *model.Block) (params *BlockParams) {
 			text = replaceNewlineBr(text)
 		}
 		text = applyHeader(style, text)
-		textComp = PlainTextWrapTemplate(templ.Raw(text))
+		if slug := r.headingSlug(b.Id); slug != "" {
+			textComp = HeadingTextTemplate(slug, templ.Raw(text))
+		} else {
+			textComp = PlainTextWrapTemplate(templ.Raw(text))
+		}
 	} else {
 		fields := b.GetFields()
 		lang := pbtypes.GetString(fields, "lang")
@@ -299,9 +332,9 @@ func (r *Renderer) makeTextBlockParams(b *model.Block) (params *BlockParams) {
 	case model.BlockContentText_Toggle:
 		externalComp := ToggleMarkerTemplate(utils.GetColor(color))
 		innerFlex = append(innerFlex, externalComp, textComp)
+		blockParams.IsDetails = r.Config.SemanticHtml
 	case model.BlockContentText_Numbered:
-		number := r.BlockNumbers[b.Id]
-		externalComp := NumberMarkerTemplate(fmt.Sprintf("%d", number))
+		externalComp := NumberMarkerTemplate(r.listMarker(b.Id))
 		innerFlex = append(innerFlex, externalComp, textComp)
 	case model.BlockContentText_Marked:
 		externalComp := BulletMarkerTemplate(color)
@@ -346,15 +379,24 @@ func (r *Renderer) makeTextBlockParams(b *model.Block) (params *BlockParams) {
 	case model.BlockContentText_Quote:
 		blockParams.Additional = AdditionalQuoteTemplate(color)
 		blockParams.AdditionalClasses = append(blockParams.AdditionalClasses, "textColor-"+color)
+		if r.Config.SemanticHtml {
+			textComp = QuoteTextTemplate(templ.Raw(text))
+		}
 		innerFlex = append(innerFlex, textComp)
 	case model.BlockContentText_Checkbox:
 		var checkboxComp templ.Component
-		if blockText.Checked {
+		if r.Config.SemanticHtml {
+			inputId := "checkbox-" + b.Id
+			checkboxComp = CheckboxInputTemplate(inputId, blockText.Checked)
+			textComp = LabelTextTemplate(inputId, templ.Raw(text))
+		} else if blockText.Checked {
 			checkboxComp = CheckboxCheckedTemplate()
-			classes = append(classes, "isChecked")
 		} else {
 			checkboxComp = CheckboxUncheckedTemplate()
 		}
+		if blockText.Checked {
+			classes = append(classes, "isChecked")
+		}
 		innerFlex = append(innerFlex, checkboxComp, textComp)
 	default:
 		innerFlex = append(innerFlex, textComp)
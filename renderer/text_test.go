@@ -91,12 +91,76 @@ func TestMakeRenderText(t *testing.T) {
 		assert.NoError(t, err)
 		pathAssertions := []pathAssertion{
 			{"div.flex > div.text > a.markuplink > attrs[href]", "anytype://object?objectId=anytypeId&spaceId=spaceId"},
+			{"div.flex > div.text > a.markuplink > attrs[rel]", "noopener noreferrer nofollow"},
 			{"div.flex > div.text > a.markuplink > Content", "test"},
 		}
 
 		assertHtmlTag(t, tag, pathAssertions)
 
 	})
+	t.Run("link in markdown", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		actual := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Link, "https://example.com/?a=1&b=\"2\""))
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		pathAssertions := []pathAssertion{
+			{"div.flex > div.text > a.markuplink > attrs[href]", "https://example.com/?a=1&b=\"2\""},
+			{"div.flex > div.text > a.markuplink > attrs[rel]", "noopener noreferrer nofollow"},
+			{"div.flex > div.text > a.markuplink > Content", "test"},
+		}
+		assertHtmlTag(t, tag, pathAssertions)
+	})
+	t.Run("javascript link in markdown", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		actual := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Link, "javascript:alert(1)"))
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		pathAssertions := []pathAssertion{
+			{"div.flex > div.text > markupobject.disabled > Content", "test"},
+		}
+		assertHtmlTag(t, tag, pathAssertions)
+	})
+	t.Run("emoji with skin tone", func(t *testing.T) {
+		// given
+		r := NewTestRenderer()
+
+		// when
+		actual := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Emoji, "👍🏾"))
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		pathAssertions := []pathAssertion{
+			{"div.flex > div.text > markupemoji > span.smile > div.iconObject > img.smileImage > attrs[src]", "/emojies/1f44d-1f3fe.png"},
+			{"div.flex > div.text > markupemoji > span.smile > div.iconObject > img.smileImage > attrs[alt]", "👍🏾"},
+		}
+		assertHtmlTag(t, tag, pathAssertions)
+	})
+	t.Run("emoji in native mode", func(t *testing.T) {
+		// given
+		r := NewTestRenderer(WithConfig(RenderConfig{EmojiMode: EmojiModeNative}))
+
+		// when
+		actual := r.makeTextBlockParams(textBlockWithMark(model.BlockContentTextMark_Emoji, "👨‍👩‍👧"))
+
+		// then
+		tag, err := blockParamsToHtmlTag(actual)
+		assert.NoError(t, err)
+		pathAssertions := []pathAssertion{
+			{"div.flex > div.text > markupemoji > span.smile > div.iconObject > span.smileNative > Content", "👨‍👩‍👧"},
+		}
+		assertHtmlTag(t, tag, pathAssertions)
+	})
 	t.Run("object is missing", func(t *testing.T) {
 		// given
 		r := NewTestRenderer()
@@ -140,6 +204,7 @@ func TestMakeRenderText(t *testing.T) {
 	t.Run("anytype object mention in markdown", func(t *testing.T) {
 		// given
 		r := NewTestRenderer(
+			WithConfig(RenderConfig{StaticFilesPath: "https://cdn.example.com/static"}),
 			WithCachedPbFiles(map[string]*pb.SnapshotWithType{
 				filepath.Join("objects", "anytypeId.pb"): {
 					SbType: model.SmartBlockType_Page,
@@ -184,14 +249,30 @@ func TestMakeRenderText(t *testing.T) {
 
 		pathAssertions := []pathAssertion{
 			{"div.flex > div.text > a.markupmention.withImage > attrs[href]", "anytype://object?objectId=anytypeId&spaceId=spaceId"},
-			{"div.flex > div.text > a.markupmention.withImage > span.smile > div.iconObject.withDefault.c20 > img.iconCommon > attrs[src]", "/img/icon/default/page.svg"},
-			{"div.flex > div.text > a.markupmention.withImage > img.space > attrs[src]", "/static/img/space.svg"},
+			{"div.flex > div.text > a.markupmention.withImage > attrs[rel]", "noopener noreferrer nofollow"},
+			{"div.flex > div.text > a.markupmention.withImage > span.smile > div.iconObject.withDefault.c20 > img.iconCommon > attrs[src]", "https://cdn.example.com/static/img/icon/default/page.svg"},
+			{"div.flex > div.text > a.markupmention.withImage > img.space > attrs[src]", "https://cdn.example.com/static/img/space.svg"},
 			{"div.flex > div.text > a.markupmention.withImage > span.name > Content", "test"},
 		}
 		assertHtmlTag(t, tag, pathAssertions)
 	})
 }
 
+func textBlockWithMark(markType model.BlockContentTextMarkType, param string) *model.Block {
+	return &model.Block{Content: &model.BlockContentOfText{Text: &model.BlockContentText{
+		Text: "test",
+		Marks: &model.BlockContentTextMarks{
+			Marks: []*model.BlockContentTextMark{
+				{
+					Range: &model.Range{From: 0, To: 4},
+					Type:  markType,
+					Param: param,
+				},
+			},
+		},
+	}}}
+}
+
 func blockParamsToHtmlTag(actual *BlockParams) (*htmltag.Tag, error) {
 	builder := strings.Builder{}
 	err := actual.Content.Render(context.Background(), &builder)
@@ -213,3 +294,67 @@ func assertHtmlTag(t *testing.T, tag *htmltag.Tag, pathAssertions []pathAssertio
 	}
 
 }
+
+func TestFormatListNumber(t *testing.T) {
+	tests := []struct {
+		number   int
+		level    int
+		expected string
+	}{
+		{1, 0, "1"},
+		{12, 0, "12"},
+		{1, 1, "a"},
+		{26, 1, "z"},
+		{27, 1, "aa"},
+		{53, 1, "ba"},
+		{1, 2, "i"},
+		{4, 2, "iv"},
+		{14, 2, "xiv"},
+		{1994, 2, "mcmxciv"},
+		{3, 3, "3"},
+		{3, 4, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatListNumber(tt.number, tt.level))
+		})
+	}
+}
+
+func TestHydrateNumberBlocks(t *testing.T) {
+	t.Run("nested items inside columns", func(t *testing.T) {
+		// given
+		nested := textBlock("nested", model.BlockContentText_Numbered, "nested")
+		inColumn := textBlock("inColumn", model.BlockContentText_Numbered, "in column")
+		inColumn.ChildrenIds = []string{"nested"}
+		column := &model.Block{Id: "column", ChildrenIds: []string{"inColumn"}, Content: &model.BlockContentOfLayout{Layout: &model.BlockContentLayout{Style: model.BlockContentLayout_Column}}}
+		row := &model.Block{Id: "row", ChildrenIds: []string{"column"}, Content: &model.BlockContentOfLayout{Layout: &model.BlockContentLayout{Style: model.BlockContentLayout_Row}}}
+		r := newPageRenderer(textBlock("first", model.BlockContentText_Numbered, "first"), row)
+		r.BlocksById["column"] = column
+		r.BlocksById["inColumn"] = inColumn
+		r.BlocksById["nested"] = nested
+
+		// when
+		r.hydrateNumberBlocks()
+
+		// then
+		assert.Equal(t, "1", r.listMarker("first"))
+		assert.Equal(t, "2", r.listMarker("inColumn"))
+		assert.Equal(t, "a", r.listMarker("nested"))
+	})
+	t.Run("any other block restarts numbering", func(t *testing.T) {
+		// given
+		r := newPageRenderer(
+			textBlock("first", model.BlockContentText_Numbered, "first"),
+			&model.Block{Id: "divider", Content: &model.BlockContentOfDiv{Div: &model.BlockContentDiv{}}},
+			textBlock("second", model.BlockContentText_Numbered, "second"),
+		)
+
+		// when
+		r.hydrateNumberBlocks()
+
+		// then
+		assert.Equal(t, 1, r.BlockNumbers["first"])
+		assert.Equal(t, 1, r.BlockNumbers["second"])
+	})
+}
@@ -0,0 +1,50 @@
+package renderer
+
+import (
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/anyproto/anytype-publish-renderer/renderer/htmlsanitizer"
+)
+
+// rel for every link whose target comes from the published content
+const linkRel = "noopener noreferrer nofollow"
+
+// DefaultAllowedUrlSchemes is used when RenderConfig.AllowedUrlSchemes is empty
+var DefaultAllowedUrlSchemes = []string{"http", "https", "mailto", "tel", "anytype"}
+
+func (r *Renderer) allowedUrlSchemes() []string {
+	if len(r.Config.AllowedUrlSchemes) != 0 {
+		return r.Config.AllowedUrlSchemes
+	}
+	return DefaultAllowedUrlSchemes
+}
+
+// sanitizeUrl checks user-controlled url against RenderConfig.AllowedUrlSchemes
+func (r *Renderer) sanitizeUrl(rawUrl string) (string, bool) {
+	cleanUrl, ok := htmlsanitizer.SanitizeUrl(rawUrl, r.allowedUrlSchemes())
+	if !ok && rawUrl != "" {
+		log.Warn("url is not allowed, dropping it", zap.String("url", rawUrl))
+	}
+	return cleanUrl, ok
+}
+
+// sanitizeEmbedUrl checks embed source, which must be both allowed by config and be a web url
+func (r *Renderer) sanitizeEmbedUrl(rawUrl string) (string, bool) {
+	// embeds are loaded into iframes, so only web schemes make sense there
+	schemes := make([]string, 0, len(htmlsanitizer.DefaultUrlSchemes))
+	for _, scheme := range r.allowedUrlSchemes() {
+		if slices.Contains(htmlsanitizer.DefaultUrlSchemes, scheme) {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	cleanUrl, ok := htmlsanitizer.SanitizeUrl(rawUrl, schemes)
+	if !ok || !strings.Contains(cleanUrl, "://") {
+		log.Warn("embed url is not allowed, dropping it", zap.String("url", rawUrl))
+		return "", false
+	}
+	return cleanUrl, true
+}
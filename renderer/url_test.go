@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRendererSanitizeUrl(t *testing.T) {
+	t.Run("default schemes", func(t *testing.T) {
+		r := NewTestRenderer()
+
+		_, ok := r.sanitizeUrl("ftp://example.com/file")
+
+		assert.False(t, ok)
+	})
+	t.Run("configured schemes", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{AllowedUrlSchemes: []string{"https", "ftp"}}))
+
+		ftpUrl, ftpOk := r.sanitizeUrl("ftp://example.com/file")
+		_, mailtoOk := r.sanitizeUrl("mailto:a@example.com")
+
+		assert.True(t, ftpOk)
+		assert.Equal(t, "ftp://example.com/file", ftpUrl)
+		assert.False(t, mailtoOk)
+	})
+	t.Run("embed url must be absolute web url", func(t *testing.T) {
+		r := NewTestRenderer()
+
+		_, httpsOk := r.sanitizeEmbedUrl("https://www.youtube.com/embed/id")
+		_, mailtoOk := r.sanitizeEmbedUrl("mailto:a@example.com")
+		_, relativeOk := r.sanitizeEmbedUrl("/embed/iframe.html")
+
+		assert.True(t, httpsOk)
+		assert.False(t, mailtoOk)
+		assert.False(t, relativeOk)
+	})
+	t.Run("embed url respects configured schemes", func(t *testing.T) {
+		r := NewTestRenderer(WithConfig(RenderConfig{AllowedUrlSchemes: []string{"https"}}))
+
+		_, httpOk := r.sanitizeEmbedUrl("http://www.youtube.com/embed/id")
+
+		assert.False(t, httpOk)
+	})
+}
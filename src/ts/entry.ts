@@ -69,7 +69,8 @@ function renderCover () {
 };
 
 function renderToggles () {
-    $('.block.textToggle').each((i, block) => {
+    // semantic toggles are <details> and open natively
+    $('.block.textToggle:not(details)').each((i, block) => {
 		block = $(block);
 		block.find('> .content .marker.toggle').off('click').on('click', function () {
 			block.toggleClass('isToggled');
@@ -226,7 +227,7 @@ function renderPdf () {
 						viewport: viewport,
 					};
 
-					page.render(renderContext);
+					page.render(renderContext).promise.then(() => block.addClass('isLoaded'));
 				});
 			};
 
@@ -302,6 +303,274 @@ function renderMenu () {
 	});
 };
 
+function renderPreviews () {
+	const win = $(window);
+	const links = $('[data-preview-id]');
+	if (!links.length) {
+		return;
+	};
+
+	const wrap = $('<div id="previewCard" class="previewWrap" role="tooltip"></div>');
+	$('body').append(wrap);
+
+	let current = null;
+	let timeout = 0;
+
+	const show = (el) => {
+		const { previewId } = el.data();
+		const template = document.getElementById(`preview-${previewId}`) as HTMLTemplateElement;
+		if (!template || (current == el.get(0))) {
+			return;
+		};
+
+		current = el.get(0);
+		wrap.empty().append(template.content.cloneNode(true));
+		renderPrimitivesSvgs(wrap.get(0));
+
+		const { left, top } = el.offset();
+		const st = win.scrollTop();
+		const ww = win.width();
+		const wh = win.height();
+		const cw = wrap.outerWidth();
+		const ch = wrap.outerHeight();
+		const eh = el.outerHeight();
+
+		let y = top - st + eh + 4;
+		if (y + ch > wh) {
+			y = top - st - ch - 4;
+		};
+
+		wrap.css({ left: Math.max(8, Math.min(left, ww - cw - 8)), top: Math.max(8, y) });
+		el.attr('aria-describedby', 'previewCard');
+		raf(() => wrap.addClass('show'));
+	};
+
+	const hide = () => {
+		if (!current) {
+			return;
+		};
+
+		$(current).removeAttr('aria-describedby');
+		current = null;
+		wrap.removeClass('show');
+	};
+
+	links.off('mouseenter focusin').on('mouseenter focusin', function () {
+		const el = $(this);
+
+		window.clearTimeout(timeout);
+		timeout = window.setTimeout(() => show(el), 300);
+	});
+
+	links.off('mouseleave focusout').on('mouseleave focusout', () => {
+		window.clearTimeout(timeout);
+		hide();
+	});
+
+	$(document).on('keydown', e => {
+		if (e.key.toLowerCase() == 'escape') {
+			window.clearTimeout(timeout);
+			hide();
+		};
+	});
+};
+
+function renderGallery () {
+	const images = $('img[data-gallery]');
+	if (!images.length) {
+		return;
+	};
+
+	const body = $('body');
+	const box = $(`
+		<div id="lightbox" class="lightbox" role="dialog" aria-modal="true" aria-label="Image viewer" tabindex="-1">
+			<button type="button" class="lightboxButton lightboxClose" aria-label="Close"></button>
+			<button type="button" class="lightboxButton lightboxArrow prev" aria-label="Previous image"></button>
+			<figure class="lightboxFigure">
+				<img class="lightboxImage" alt="" />
+				<figcaption class="lightboxCounter" aria-live="polite"></figcaption>
+			</figure>
+			<button type="button" class="lightboxButton lightboxArrow next" aria-label="Next image"></button>
+		</div>
+	`);
+	body.append(box);
+
+	const image = box.find('.lightboxImage');
+	const counter = box.find('.lightboxCounter');
+	const arrows = box.find('.lightboxArrow');
+
+	let items = [];
+	let index = 0;
+	let opener = null;
+
+	const show = (i: number) => {
+		index = (i + items.length) % items.length;
+
+		const item = $(items[index]);
+		image.attr({ src: item.data('full'), alt: item.attr('alt') || '' });
+		counter.text(items.length > 1 ? `${index + 1} / ${items.length}` : '');
+	};
+
+	const open = (el) => {
+		items = images.filter((i, item) => $(item).data('gallery') == el.data('gallery')).toArray();
+		items.sort((a, b) => $(a).data('galleryIndex') - $(b).data('galleryIndex'));
+		opener = el.get(0);
+
+		arrows.toggle(items.length > 1);
+		show(Number(el.data('galleryIndex')) || 0);
+		body.addClass('withLightbox');
+		box.addClass('show');
+		box.trigger('focus');
+	};
+
+	const close = () => {
+		box.removeClass('show');
+		body.removeClass('withLightbox');
+		image.removeAttr('src');
+
+		if (opener) {
+			opener.focus();
+			opener = null;
+		};
+	};
+
+	images.off('click keydown').on('click keydown', function (e) {
+		if ((e.type == 'keydown') && !['enter', ' '].includes(e.key.toLowerCase())) {
+			return;
+		};
+
+		e.preventDefault();
+		open($(this));
+	});
+
+	box.find('.lightboxClose').on('click', close);
+	box.find('.lightboxArrow.prev').on('click', () => show(index - 1));
+	box.find('.lightboxArrow.next').on('click', () => show(index + 1));
+	box.on('click', e => {
+		if (e.target == box.get(0)) {
+			close();
+		};
+	});
+
+	box.on('keydown', e => {
+		const key = e.key.toLowerCase();
+
+		switch (key) {
+			case 'escape': {
+				close();
+				break;
+			};
+
+			case 'arrowleft': {
+				show(index - 1);
+				break;
+			};
+
+			case 'arrowright': {
+				show(index + 1);
+				break;
+			};
+
+			// focus stays in the dialog while it is open
+			case 'tab': {
+				const buttons = box.find('button:visible');
+				const first = buttons.first().get(0);
+				const last = buttons.last().get(0);
+
+				if (e.shiftKey && ((document.activeElement == first) || (document.activeElement == box.get(0)))) {
+					e.preventDefault();
+					last.focus();
+				} else
+				if (!e.shiftKey && (document.activeElement == last)) {
+					e.preventDefault();
+					first.focus();
+				};
+				break;
+			};
+		};
+	});
+};
+
+function renderAudioDuration () {
+	$('.audioMeta').each((i, item) => {
+		const meta = $(item);
+		const audio = meta.siblings('audio').get(0) as HTMLAudioElement;
+		if (!audio) {
+			return;
+		};
+
+		const show = () => {
+			const duration = Math.round(audio.duration);
+			if (!isFinite(duration)) {
+				return;
+			};
+
+			const h = Math.floor(duration / 3600);
+			const m = Math.floor((duration % 3600) / 60);
+			const s = String(duration % 60).padStart(2, '0');
+
+			meta.find('.duration').text(h ? `${h}:${String(m).padStart(2, '0')}:${s}` : `${m}:${s}`);
+		};
+
+		if (audio.readyState >= 1) {
+			show();
+		} else {
+			$(audio).one('loadedmetadata', show);
+		};
+	});
+};
+
+function renderEmbedFacades () {
+	const facades = $('.embedFacade');
+	if (!facades.length) {
+		return;
+	};
+
+	const storageKey = (provider: string) => `embedConsent-${provider}`;
+
+	const isRemembered = (provider: string): boolean => {
+		try {
+			return !!localStorage.getItem(storageKey(provider));
+		} catch (e) {
+			return false;
+		};
+	};
+
+	const remember = (provider: string) => {
+		try {
+			localStorage.setItem(storageKey(provider), '1');
+		} catch (e) {
+			console.error('failed to remember embed consent', e);
+		};
+	};
+
+	const load = (facade) => {
+		const iframe = facade.siblings('iframe[data-src]');
+
+		iframe.attr('src', iframe.attr('data-src')).removeAttr('data-src');
+		facade.remove();
+	};
+
+	facades.each((i, item) => {
+		const facade = $(item);
+		const { provider } = facade.data();
+
+		if (isRemembered(provider)) {
+			load(facade);
+			return;
+		};
+
+		facade.find('.embedLoad').off('click').on('click', () => {
+			if (facade.find('.embedRemember').is(':checked')) {
+				remember(provider);
+				$(`.embedFacade[data-provider="${provider}"]`).each((i, item) => load($(item)));
+			} else {
+				load(facade);
+			};
+		});
+	});
+};
+
 function renderTable () {
 	const blocks = $('.block.blockTable');
 
@@ -371,8 +640,8 @@ function renderColumn () {
 	});
 };
 
-function renderPrimitivesSvgs () {
-    document.querySelectorAll(".svg-container").forEach(container => {
+function renderPrimitivesSvgs (root: ParentNode = document) {
+    root.querySelectorAll(".svg-container").forEach(container => {
         const svgUrl = container.getAttribute("data-src");
         const color = container.getAttribute("data-color");
 
@@ -429,6 +698,7 @@ $(document).ready(() => {
 		renderColumn,
 		renderCover,
 		renderAnalyticsEvents, 
+		renderEmbedFacades,
 		renderToggles, 
 		renderLatex, 
 		renderMermaid, 
@@ -437,6 +707,9 @@ $(document).ready(() => {
 		renderInlineLatex,
 		renderPdf,
 		renderMenu,
+		renderPreviews,
+		renderGallery,
+		renderAudioDuration,
 		renderPrimitivesSvgs,
 	];
 
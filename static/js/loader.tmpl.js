@@ -1,17 +1,21 @@
 (function() {
 	const chunks = %CHUNKS%;
 	const cssFiles = %CSS%;
+	// build directory is set by renderer, static files may be served from any path or domain,
+	// pages rendered without it load the build next to the loader
+	const script = document.currentScript;
+	const build = script.getAttribute('data-build') || script.src.replace(/js\/loader\.js([?#].*)?$/, 'js/build/');
 
 	cssFiles.forEach(file => {
 		const link = document.createElement('link');
 		link.rel = 'stylesheet';
-		link.href = '/static/js/build/' + file;
+		link.href = build + file;
 		document.head.appendChild(link);
 	});
 
 	chunks.forEach(file => {
 		const script = document.createElement('script');
-		script.src = '/static/js/build/' + file;
+		script.src = build + file;
 		script.defer = true;
 		script.onerror = function() {
 			console.error(`Error loading chunk: ${file}`);